@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	stdimage "image"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"tilemap-generator/image"
+	"tilemap-generator/mapgen/batch"
+	"tilemap-generator/mapgen/recipe"
+	"tilemap-generator/mapgen/world"
+)
+
+func (c *cli) batch(args []string) error {
+	fs := c.flags("batch")
+	var rf recipeFlags
+	rf.register(fs)
+	seedSpec := fs.String("seeds", "1-16", "seeds as a list and ranges, e.g. 1-16 or 3,7,42-44")
+	workers := fs.Int("workers", 0, "worlds generated at once (default: number of CPUs)")
+	out := fs.String("out", "batch", "output directory")
+	format := fs.String("format", "png", "output format of each world: png or tmap")
+	noRecipe := fs.Bool("no-recipe", false, "do not save a recipe next to each world")
+	sheet := fs.String("sheet", "", "contact sheet file (default <out>/contact_sheet.png), - disables it")
+	thumb := fs.Int("thumb", 256, "contact sheet: thumbnail size in pixels")
+	columns := fs.Int("columns", 0, "contact sheet: number of columns (default: square grid)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if *format != "png" && *format != "tmap" {
+		return usageErrorf("unknown output format %q, want png or tmap", *format)
+	}
+	seeds, err := batch.ParseSeeds(*seedSpec)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	if *sheet == "" {
+		*sheet = filepath.Join(*out, "contact_sheet.png")
+	}
+
+	r, err := rf.load()
+	if err != nil {
+		return err
+	}
+	if err := r.Prepare(); err != nil {
+		return invalidInput(err)
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+
+	// Миры не держим в памяти: каждый сразу сохраняется и сжимается до миниатюры
+	thumbs := make([]stdimage.Image, len(seeds))
+	handle := func(i int, w *world.World) error {
+		name := filepath.Join(*out, fmt.Sprintf("seed_%d", w.Seed))
+		img := image.CreateImageFromWorld(w)
+		thumbs[i] = image.Thumbnail(img, *thumb)
+
+		if !*noRecipe {
+			rc := *r
+			rc.Params.Seed = w.Seed
+			if err := recipe.Save(name+".recipe.json", &rc); err != nil {
+				return fmt.Errorf("saving recipe: %w", err)
+			}
+		}
+		if *format == "tmap" {
+			return saveWorld(name+".tmap", w)
+		}
+		return image.SaveImage(img, name+".png")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	summaries, err := batch.Run(ctx, r, seeds, batch.Options{Workers: *workers}, handle)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Generated %d worlds in %s\n", len(summaries), *out)
+
+	if *sheet == "-" {
+		return nil
+	}
+	items := make([]image.ContactSheetItem, len(summaries))
+	for i, s := range summaries {
+		items[i] = image.ContactSheetItem{Image: thumbs[i], Lines: summaryLines(s)}
+	}
+	img, err := image.CreateContactSheet(items, image.ContactSheetOptions{ThumbSize: *thumb, Columns: *columns})
+	if err != nil {
+		return err
+	}
+	if err := image.SaveImage(img, *sheet); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Saved %s\n", *sheet)
+	return nil
+}
+
+// summaryLines — подпись миниатюры: сид, доля суши и три самых крупных биома.
+func summaryLines(s batch.Summary) []string {
+	lines := []string{
+		fmt.Sprintf("Seed %d", s.Seed),
+		fmt.Sprintf("Land %.1f%% · %d biomes", s.LandRatio*100, len(s.Biomes)),
+	}
+
+	var total int64
+	for _, b := range s.Biomes {
+		total += b.Cells
+	}
+	var top []string
+	for _, b := range s.Biomes[:min(3, len(s.Biomes))] {
+		name := b.NameRU
+		if name == "" {
+			name = b.Name
+		}
+		top = append(top, fmt.Sprintf("%s %.0f%%", name, float64(b.Cells)/float64(total)*100))
+	}
+	return append(lines, strings.Join(top, ", "))
+}
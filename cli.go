@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/generator"
+	"tilemap-generator/mapgen/recipe"
+	"tilemap-generator/mapgen/world"
+)
+
+// Коды выхода: скрипты различают неверный вызов, неверные входные данные
+// (рецепт, биомы, файл мира) и прочие ошибки вроде ввода-вывода.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+	exitInvalid = 3
+)
+
+type cliError struct {
+	code int
+	err  error
+}
+
+func (e *cliError) Error() string { return e.err.Error() }
+func (e *cliError) Unwrap() error { return e.err }
+
+func usageErrorf(format string, args ...any) error {
+	return &cliError{exitUsage, fmt.Errorf(format, args...)}
+}
+
+func invalidInput(err error) error {
+	return &cliError{exitInvalid, err}
+}
+
+const usage = `Usage: tilemap-generator <command> [flags]
+
+Commands:
+  generate  generate a world and save it as PNG or .tmap (default command)
+  render    render a world in one of the styles
+  export    export a world to game engines, 3D, vector or tile formats
+  batch     generate worlds for many seeds and a contact sheet
+  search    find seeds whose worlds meet constraints
+  stats     print statistics of a world
+  serve     serve tiles and the live preview over HTTP
+
+Run "tilemap-generator <command> -h" for the flags of a command.
+`
+
+type cli struct {
+	stdout, stderr io.Writer
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	c := &cli{stdout, stderr}
+
+	// Без команды — generate, как раньше запускался main без аргументов
+	cmd := "generate"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	commands := map[string]func([]string) error{
+		"generate": c.generate,
+		"render":   c.render,
+		"export":   c.export,
+		"batch":    c.batch,
+		"search":   c.search,
+		"stats":    c.stats,
+		"serve":    c.serve,
+	}
+
+	var err error
+	if fn, ok := commands[cmd]; ok {
+		err = fn(args)
+	} else if cmd == "help" {
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	} else {
+		fmt.Fprint(stderr, usage)
+		err = usageErrorf("unknown command %q", cmd)
+	}
+
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	fmt.Fprintf(stderr, "tilemap-generator %s: %v\n", cmd, err)
+
+	var ce *cliError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+	return exitFailure
+}
+
+func (c *cli) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// parse разбирает флаги; лишние позиционные аргументы — ошибка вызова.
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &cliError{exitUsage, err}
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return nil
+}
+
+// source — откуда команды render, export и stats берут мир.
+type source struct {
+	recipe string
+	world  string
+
+	// Параметры генерации мира из -recipe с выбранным сидом; load заполняет их,
+	// для -world остаётся nil.
+	params *generator.WorldGeneratorParams
+}
+
+func (s *source) register(fs *flag.FlagSet) {
+	fs.StringVar(&s.recipe, "recipe", "", "recipe file to regenerate the world from")
+	fs.StringVar(&s.world, "world", "", "world file (.tmap) saved by generate")
+}
+
+func (s *source) load() (*world.World, error) {
+	switch {
+	case s.recipe != "" && s.world != "":
+		return nil, usageErrorf("use either -recipe or -world, not both")
+	case s.recipe != "":
+		r, err := recipe.Load(s.recipe)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			return nil, invalidInput(err)
+		}
+		w, err := r.Generate()
+		if err != nil {
+			return nil, invalidInput(err)
+		}
+		params := r.Params
+		params.Seed = w.Seed
+		s.params = &params
+		return w, nil
+	case s.world != "":
+		file, err := os.Open(s.world)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		w := &world.World{}
+		if err := w.Load(file); err != nil {
+			return nil, invalidInput(fmt.Errorf("%s: %w", s.world, err))
+		}
+		return w, nil
+	}
+	return nil, usageErrorf("a world is required: pass -recipe or -world")
+}
+
+// loadBiomes читает набор биомов из файла или, если путь не задан, из biomes.json
+// со встроенной таблицей на случай его отсутствия.
+func loadBiomes(path string) (*biome.Set, error) {
+	if path == "" {
+		set, err := loadBiomeSet()
+		if err != nil {
+			return nil, invalidInput(err)
+		}
+		return set, nil
+	}
+
+	set, err := biome.LoadSet(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, invalidInput(err)
+	}
+	return set, err
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"tilemap-generator/export"
+	"tilemap-generator/image"
+	"tilemap-generator/mapgen/world"
+)
+
+func (c *cli) export(args []string) error {
+	fs := c.flags("export")
+	var src source
+	src.register(fs)
+	format := fs.String("format", "", "tmx, tiled-json, ldtk, godot, obj, stl, glb, svg, geojson, tiles, r16, r32 or tmap")
+	out := fs.String("out", "", "output file (a directory for tiles)")
+	tileSize := fs.Int("tile-size", 0, "tile size in pixels (default 16, 256 for tiles)")
+	chunk := fs.Int64("chunk", 0, "ldtk: level size in cells, 0 exports one level")
+	step := fs.Int("step", 1, "obj, stl, glb: use every n-th cell as a vertex")
+	vertical := fs.Float64("vertical-scale", 0, "obj, stl, glb: height of the highest point (default 32)")
+	simplify := fs.Float64("simplify", 0, "svg, geojson: simplification tolerance in cells")
+	downsample := fs.String("downsample", "majority", "tiles: majority or average")
+	minZoom := fs.Int("min-zoom", 0, "tiles: lowest zoom level")
+	maxZoom := fs.Int("max-zoom", 0, "tiles: highest zoom level (default: one cell per pixel)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return usageErrorf("-out is required")
+	}
+
+	var save func(w *world.World) error
+	switch *format {
+	case "tmx", "tiled-json":
+		save = func(w *world.World) error {
+			opts := export.TiledOptions{Tileset: export.TiledTileset{TileWidth: *tileSize}}
+			if err := saveTileset(*out, w, *tileSize); err != nil {
+				return err
+			}
+			if *format == "tmx" {
+				return export.SaveTMX(*out, w, opts)
+			}
+			return export.SaveTiledJSON(*out, w, opts)
+		}
+	case "ldtk":
+		save = func(w *world.World) error {
+			return export.SaveLDtk(*out, w, export.LDtkOptions{GridSize: *tileSize, ChunkSize: *chunk, Params: src.params})
+		}
+	case "godot":
+		save = func(w *world.World) error {
+			if err := saveTileset(*out, w, *tileSize); err != nil {
+				return err
+			}
+			tileSet := strings.TrimSuffix(*out, filepath.Ext(*out)) + ".tres"
+			return export.SaveGodot(*out, tileSet, w, export.GodotOptions{
+				TileSize: *tileSize,
+				TileSet:  "res://" + filepath.Base(tileSet),
+			})
+		}
+	case "obj", "stl", "glb":
+		save = func(w *world.World) error {
+			m, err := export.BuildMesh(w, export.MeshOptions{Step: *step, VerticalScale: *vertical})
+			if err != nil {
+				return err
+			}
+			switch *format {
+			case "obj":
+				return export.SaveOBJ(*out, m)
+			case "stl":
+				return export.SaveSTL(*out, m)
+			}
+			return export.SaveGLB(*out, m)
+		}
+	case "svg":
+		save = func(w *world.World) error {
+			return export.SaveSVG(*out, w, export.VectorOptions{Simplify: *simplify})
+		}
+	case "geojson":
+		save = func(w *world.World) error {
+			return export.SaveGeoJSON(*out, w, export.VectorOptions{Simplify: *simplify})
+		}
+	case "tiles":
+		save = func(w *world.World) error {
+			opts := export.TileOptions{
+				TileSize:   *tileSize,
+				Downsample: export.Downsample(*downsample),
+				MinZoom:    *minZoom,
+			}
+			fs.Visit(func(f *flag.Flag) {
+				if f.Name == "max-zoom" {
+					opts.MaxZoom = maxZoom
+				}
+			})
+			stats, err := export.SaveTilePyramid(*out, w, opts)
+			if err == nil {
+				fmt.Fprintf(c.stdout, "Tiles: %d written, %d unchanged\n", stats.Written, stats.Skipped)
+			}
+			return err
+		}
+	case "r16", "r32":
+		save = func(w *world.World) error {
+			if !w.HasHeights() {
+				return invalidInput(image.ErrNoHeights)
+			}
+			if *format == "r16" {
+				return image.SaveR16(w, image.HeightmapOptions{}, *out)
+			}
+			return image.SaveR32(w, image.HeightmapOptions{}, *out)
+		}
+	case "tmap":
+		save = func(w *world.World) error { return saveWorld(*out, w) }
+	case "":
+		return usageErrorf("-format is required")
+	default:
+		return usageErrorf("unknown export format %q", *format)
+	}
+
+	w, err := src.load()
+	if err != nil {
+		return err
+	}
+	if err := save(w); err != nil {
+		if errors.Is(err, export.ErrInvalid) {
+			return invalidInput(err)
+		}
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "Saved %s\n", *out)
+	return nil
+}
+
+// saveTileset кладёт рядом с картой картинку тайлсета, на которую ссылаются
+// экспорты Tiled и Godot (biomes.png по умолчанию).
+func saveTileset(out string, w *world.World, tileSize int) error {
+	if tileSize <= 0 {
+		tileSize = 16
+	}
+	img := image.CreatePaletteTileset(w.Palette(), tileSize, tileSize)
+	return image.SaveImage(img, filepath.Join(filepath.Dir(out), "biomes.png"))
+}
@@ -0,0 +1,186 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+// Экспорт в текстовые ресурсы Godot 4: TileSet (.tres) с атласом и TileMap (.tscn).
+// Вывод полностью детерминирован — клетки идут построчно, тайлы отсортированы,
+// поэтому повторный экспорт того же мира даёт тот же файл.
+
+type AtlasCoords struct {
+	X, Y int
+}
+
+// AtlasMapping сопоставляет биомам координаты тайла в атласе.
+// Ключи ищутся так же, как в TileMapping: NameRU, затем Name.
+type AtlasMapping struct {
+	Coords  map[string]AtlasCoords
+	Default AtlasCoords
+}
+
+func (m AtlasMapping) AtlasCoords(b biome.Data) AtlasCoords {
+	if c, ok := lookup(m.Coords, b); ok {
+		return c
+	}
+	return m.Default
+}
+
+// AtlasFromTiles раскладывает линейные номера тайлов по атласу в columns столбцов.
+func AtlasFromTiles(m TileMapping, columns int) AtlasMapping {
+	columns = max(columns, 1)
+	atlas := AtlasMapping{
+		Coords:  make(map[string]AtlasCoords, len(m.Tiles)),
+		Default: AtlasCoords{X: m.Default % columns, Y: m.Default / columns},
+	}
+	for key, id := range m.Tiles {
+		atlas.Coords[key] = AtlasCoords{X: id % columns, Y: id / columns}
+	}
+	return atlas
+}
+
+type GodotOptions struct {
+	// Размер тайла в пикселях. Default: 16.
+	TileSize int
+	// Путь к картинке атласа внутри проекта Godot. Default: "res://biomes.png".
+	Texture string
+	// Путь, по которому сцена ссылается на TileSet. Default: "res://biomes.tres".
+	TileSet string
+	// Имя корневого узла сцены. Default: "World".
+	NodeName string
+	// Если Atlas.Coords пуст, биомы раскладываются в одну строку по PaletteMapping.
+	Atlas AtlasMapping
+}
+
+func (o GodotOptions) withDefaults(w *world.World) GodotOptions {
+	if o.TileSize == 0 {
+		o.TileSize = 16
+	}
+	if o.Texture == "" {
+		o.Texture = "res://biomes.png"
+	}
+	if o.TileSet == "" {
+		o.TileSet = "res://biomes.tres"
+	}
+	if o.NodeName == "" {
+		o.NodeName = "World"
+	}
+	if len(o.Atlas.Coords) == 0 {
+		m := PaletteMapping(w)
+		o.Atlas = AtlasFromTiles(m, len(m.Tiles))
+	}
+	return o
+}
+
+// atlasTiles — используемые тайлы атласа в стабильном порядке (по строкам, затем по столбцам)
+// с первым биомом, который на них отображается.
+func (o GodotOptions) atlasTiles(w *world.World) ([]AtlasCoords, map[AtlasCoords]biome.Data) {
+	byCoords := make(map[AtlasCoords]biome.Data)
+	var coords []AtlasCoords
+	for _, b := range w.Palette() {
+		c := o.Atlas.AtlasCoords(b)
+		if _, ok := byCoords[c]; !ok {
+			byCoords[c] = b
+			coords = append(coords, c)
+		}
+	}
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i].Y != coords[j].Y {
+			return coords[i].Y < coords[j].Y
+		}
+		return coords[i].X < coords[j].X
+	})
+	return coords, byCoords
+}
+
+func WriteGodotTileSet(out io.Writer, w *world.World, opts GodotOptions) error {
+	opts = opts.withDefaults(w)
+	coords, byCoords := opts.atlasTiles(w)
+
+	bw := bufio.NewWriter(out)
+	fmt.Fprintf(bw, "[gd_resource type=\"TileSet\" load_steps=3 format=3]\n\n")
+	fmt.Fprintf(bw, "[ext_resource type=\"Texture2D\" path=%s id=\"1_atlas\"]\n\n", strconv.Quote(opts.Texture))
+	fmt.Fprintf(bw, "[sub_resource type=\"TileSetAtlasSource\" id=\"TileSetAtlasSource_biomes\"]\n")
+	fmt.Fprintf(bw, "texture = ExtResource(\"1_atlas\")\n")
+	fmt.Fprintf(bw, "texture_region_size = Vector2i(%d, %d)\n", opts.TileSize, opts.TileSize)
+	for _, c := range coords {
+		b := byCoords[c]
+		fmt.Fprintf(bw, "%d:%d/0 = 0\n", c.X, c.Y)
+		fmt.Fprintf(bw, "%d:%d/0/custom_data_0 = %s\n", c.X, c.Y, strconv.Quote(b.Name))
+		fmt.Fprintf(bw, "%d:%d/0/custom_data_1 = %s\n", c.X, c.Y, strconv.Quote(b.NameRU))
+	}
+
+	fmt.Fprintf(bw, "\n[resource]\n")
+	fmt.Fprintf(bw, "tile_size = Vector2i(%d, %d)\n", opts.TileSize, opts.TileSize)
+	// Названия биомов — в пользовательских данных тайлов, чтобы игра могла их прочитать
+	fmt.Fprintf(bw, "custom_data_layer_0/name = \"name\"\n")
+	fmt.Fprintf(bw, "custom_data_layer_0/type = 4\n")
+	fmt.Fprintf(bw, "custom_data_layer_1/name = \"name_ru\"\n")
+	fmt.Fprintf(bw, "custom_data_layer_1/type = 4\n")
+	fmt.Fprintf(bw, "sources/0 = SubResource(\"TileSetAtlasSource_biomes\")\n")
+
+	return bw.Flush()
+}
+
+func WriteGodotScene(out io.Writer, w *world.World, opts GodotOptions) error {
+	opts = opts.withDefaults(w)
+
+	// В формате 2 координаты клетки упакованы в int16
+	if w.Width > 1<<15 || w.Height > 1<<15 {
+		return invalidf("export: godot TileMap supports at most %d cells per axis, world is %dx%d", 1<<15, w.Width, w.Height)
+	}
+
+	bw := bufio.NewWriter(out)
+	fmt.Fprintf(bw, "[gd_scene load_steps=2 format=3]\n\n")
+	fmt.Fprintf(bw, "[ext_resource type=\"TileSet\" path=%s id=\"1_tileset\"]\n\n", strconv.Quote(opts.TileSet))
+	fmt.Fprintf(bw, "[node name=%s type=\"TileMap\"]\n", strconv.Quote(opts.NodeName))
+	fmt.Fprintf(bw, "tile_set = ExtResource(\"1_tileset\")\n")
+	fmt.Fprintf(bw, "format = 2\n")
+	fmt.Fprintf(bw, "layer_0/name = \"biomes\"\n")
+
+	// Клетка — три int32: (y<<16 | x), (source_id | atlas_x<<16), (atlas_y | alternative<<16)
+	bw.WriteString("layer_0/tile_data = PackedInt32Array(")
+	first := true
+	w.Each(func(p world.Point, b biome.Data) bool {
+		c := opts.Atlas.AtlasCoords(b)
+		packed := [3]int32{
+			int32(p.Y<<16 | p.X&0xffff),
+			int32(0 | c.X<<16),
+			int32(c.Y),
+		}
+		for _, v := range packed {
+			if !first {
+				bw.WriteString(", ")
+			}
+			first = false
+			bw.WriteString(strconv.FormatInt(int64(v), 10))
+		}
+		return true
+	})
+	bw.WriteString(")\n")
+
+	fmt.Fprintf(bw, "metadata/seed = %d\n", w.Seed)
+
+	return bw.Flush()
+}
+
+// SaveGodot пишет сцену и TileSet. Путь TileSet внутри проекта задаётся opts.TileSet
+// и должен соответствовать tileSetPath.
+func SaveGodot(scenePath, tileSetPath string, w *world.World, opts GodotOptions) error {
+	err := saveFile(tileSetPath, func(out io.Writer) error {
+		return WriteGodotTileSet(out, w, opts)
+	})
+	if err != nil {
+		return err
+	}
+
+	return saveFile(scenePath, func(out io.Writer) error {
+		return WriteGodotScene(out, w, opts)
+	})
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteGodotSceneIsDeterministic(t *testing.T) {
+	opts := GodotOptions{Atlas: AtlasMapping{Coords: map[string]AtlasCoords{
+		"Liquid": {X: 0, Y: 1},
+		"Луга":   {X: 2, Y: 0},
+	}}}
+
+	var first, second bytes.Buffer
+	if err := WriteGodotScene(&first, testWorld(), opts); err != nil {
+		t.Fatalf("WriteGodotScene: %v", err)
+	}
+	if err := WriteGodotScene(&second, testWorld(), opts); err != nil {
+		t.Fatalf("WriteGodotScene: %v", err)
+	}
+	if first.String() != second.String() {
+		t.Fatal("two exports of the same world differ")
+	}
+
+	// Клетка (1, 0) — Лагуна (группа Liquid) в атласе (0, 1); клетка (0, 1) — Луга в (2, 0)
+	want := "PackedInt32Array(0, 0, 1, 1, 0, 1, 2, 131072, 0, 65536, 131072, 0, 65537, 131072, 0, 65538, 0, 1)"
+	if !strings.Contains(first.String(), want) {
+		t.Errorf("tile data mismatch, want %s in:\n%s", want, first.String())
+	}
+}
+
+func TestWriteGodotTileSetListsUsedTiles(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGodotTileSet(&buf, testWorld(), GodotOptions{}); err != nil {
+		t.Fatalf("WriteGodotTileSet: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"0:0/0 = 0", "1:0/0 = 0", "2:0/0 = 0", `2:0/0/custom_data_1 = "Луга"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("TileSet is missing %q:\n%s", want, out)
+		}
+	}
+}
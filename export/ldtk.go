@@ -0,0 +1,379 @@
+package export
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/generator"
+	"tilemap-generator/mapgen/world"
+)
+
+const ldtkVersion = "1.5.3"
+
+type LDtkOptions struct {
+	// Размер клетки в пикселях. Default: 16.
+	GridSize int
+	// Размер уровня в клетках по каждой оси. 0 — весь мир одним уровнем,
+	// иначе мир режется на чанки, и каждый становится отдельным уровнем.
+	ChunkSize int64
+	// Если Mapping.Tiles пуст, используется PaletteMapping. Значение IntGrid = номер тайла + 1,
+	// потому что 0 в LDtk означает пустую клетку.
+	Mapping TileMapping
+	// Параметры генерации попадают в поля уровней; nil — только сид.
+	Params *generator.WorldGeneratorParams
+}
+
+// Объекты LDtk пишем словарями: схема большая, а encoding/json сортирует ключи,
+// так что вывод детерминирован.
+type ldtkObject = map[string]any
+
+var ldtkIdentifierPattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// uid-ы в проекте: 1 — слой IntGrid, с 100 — поля уровней, с 1000 — уровни.
+const (
+	ldtkLayerUID      = 1
+	ldtkFirstFieldUID = 100
+	ldtkFirstLevelUID = 1000
+)
+
+type ldtkField struct {
+	identifier, kind string
+	value            any
+}
+
+func WriteLDtk(out io.Writer, w *world.World, opts LDtkOptions) error {
+	if w.Width <= 0 || w.Height <= 0 {
+		return invalidf("export: ldtk needs a non-empty world, got %dx%d", w.Width, w.Height)
+	}
+	if opts.GridSize == 0 {
+		opts.GridSize = 16
+	}
+	if len(opts.Mapping.Tiles) == 0 {
+		opts.Mapping = PaletteMapping(w)
+	}
+	chunk := opts.ChunkSize
+	if chunk <= 0 {
+		chunk = max(w.Width, w.Height)
+	}
+
+	values, groups := ldtkIntGridValues(w, opts.Mapping)
+
+	fields := []ldtkField{{"seed", "Int", w.Seed}}
+	if opts.Params != nil {
+		fields = append(fields,
+			ldtkField{"frequency", "Float", opts.Params.Frequency},
+			ldtkField{"offset_x", "Int", opts.Params.OffsetX},
+			ldtkField{"offset_y", "Int", opts.Params.OffsetY},
+		)
+	}
+	fields = append(fields, ldtkField{"chunk_x", "Int", 0}, ldtkField{"chunk_y", "Int", 0})
+
+	fieldDefs := make([]ldtkObject, 0, len(fields))
+	for i, f := range fields {
+		fieldDefs = append(fieldDefs, ldtkFieldDef(ldtkFirstFieldUID+i, f))
+	}
+
+	chunksX := (w.Width + chunk - 1) / chunk
+	chunksY := (w.Height + chunk - 1) / chunk
+	levelIID := func(cx, cy int64) string {
+		return ldtkIID(w.Seed, fmt.Sprintf("level/%d/%d", cx, cy))
+	}
+
+	levels := make([]ldtkObject, 0, chunksX*chunksY)
+	for cy := int64(0); cy < chunksY; cy++ {
+		for cx := int64(0); cx < chunksX; cx++ {
+			lo := world.Point{X: cx * chunk, Y: cy * chunk}
+			hi := world.Point{X: min(lo.X+chunk, w.Width), Y: min(lo.Y+chunk, w.Height)}
+			uid := ldtkFirstLevelUID + len(levels)
+
+			csv := make([]int, 0, (hi.X-lo.X)*(hi.Y-lo.Y))
+			w.EachIn(lo, hi, func(_ world.Point, b biome.Data) bool {
+				csv = append(csv, opts.Mapping.TileID(b)+1)
+				return true
+			})
+
+			fields[len(fields)-2].value = cx
+			fields[len(fields)-1].value = cy
+			instances := make([]ldtkObject, 0, len(fields))
+			for i, f := range fields {
+				instances = append(instances, ldtkFieldInstance(ldtkFirstFieldUID+i, f))
+			}
+
+			var neighbours []ldtkObject
+			for _, n := range []struct {
+				dx, dy int64
+				dir    string
+			}{{0, -1, "n"}, {1, 0, "e"}, {0, 1, "s"}, {-1, 0, "w"}} {
+				nx, ny := cx+n.dx, cy+n.dy
+				if nx >= 0 && ny >= 0 && nx < chunksX && ny < chunksY {
+					neighbours = append(neighbours, ldtkObject{"levelIid": levelIID(nx, ny), "dir": n.dir})
+				}
+			}
+
+			wid, hei := hi.X-lo.X, hi.Y-lo.Y
+			levels = append(levels, ldtkObject{
+				"identifier":        fmt.Sprintf("Level_%d_%d", cx, cy),
+				"iid":               levelIID(cx, cy),
+				"uid":               uid,
+				"worldX":            lo.X * int64(opts.GridSize),
+				"worldY":            lo.Y * int64(opts.GridSize),
+				"worldDepth":        0,
+				"pxWid":             wid * int64(opts.GridSize),
+				"pxHei":             hei * int64(opts.GridSize),
+				"__bgColor":         "#696A79",
+				"bgColor":           nil,
+				"useAutoIdentifier": false,
+				"bgRelPath":         nil,
+				"bgPos":             nil,
+				"bgPivotX":          0.5,
+				"bgPivotY":          0.5,
+				"__smartColor":      "#ADADB5",
+				"__bgPos":           nil,
+				"externalRelPath":   nil,
+				"fieldInstances":    instances,
+				"__neighbours":      append([]ldtkObject{}, neighbours...),
+				"layerInstances": []ldtkObject{{
+					"__identifier":       "Biomes",
+					"__type":             "IntGrid",
+					"__cWid":             wid,
+					"__cHei":             hei,
+					"__gridSize":         opts.GridSize,
+					"__opacity":          1,
+					"__pxTotalOffsetX":   0,
+					"__pxTotalOffsetY":   0,
+					"__tilesetDefUid":    nil,
+					"__tilesetRelPath":   nil,
+					"iid":                ldtkIID(w.Seed, fmt.Sprintf("layer/%d/%d", cx, cy)),
+					"levelId":            uid,
+					"layerDefUid":        ldtkLayerUID,
+					"pxOffsetX":          0,
+					"pxOffsetY":          0,
+					"visible":            true,
+					"optionalRules":      []any{},
+					"intGridCsv":         csv,
+					"autoLayerTiles":     []any{},
+					"seed":               uid,
+					"overrideTilesetUid": nil,
+					"gridTiles":          []any{},
+					"entityInstances":    []any{},
+				}},
+			})
+		}
+	}
+
+	project := ldtkObject{
+		"__header__": ldtkObject{
+			"fileType":   "LDtk Project JSON",
+			"app":        "LDtk",
+			"doc":        "https://ldtk.io/json",
+			"schema":     "https://ldtk.io/files/JSON_SCHEMA.json",
+			"appAuthor":  "Sebastien 'deepnight' Benard",
+			"appVersion": ldtkVersion,
+			"url":        "https://ldtk.io",
+		},
+		"iid":                 ldtkIID(w.Seed, "project"),
+		"jsonVersion":         ldtkVersion,
+		"appBuildId":          473703,
+		"nextUid":             ldtkFirstLevelUID + len(levels),
+		"identifierStyle":     "Free",
+		"toc":                 []any{},
+		"worldLayout":         "Free",
+		"worldGridWidth":      chunk * int64(opts.GridSize),
+		"worldGridHeight":     chunk * int64(opts.GridSize),
+		"defaultLevelWidth":   chunk * int64(opts.GridSize),
+		"defaultLevelHeight":  chunk * int64(opts.GridSize),
+		"defaultPivotX":       0,
+		"defaultPivotY":       0,
+		"defaultGridSize":     opts.GridSize,
+		"defaultEntityWidth":  opts.GridSize,
+		"defaultEntityHeight": opts.GridSize,
+		"bgColor":             "#40465B",
+		"defaultLevelBgColor": "#696A79",
+		"minifyJson":          false,
+		"externalLevels":      false,
+		"exportTiled":         false,
+		"simplifiedExport":    false,
+		"imageExportMode":     "None",
+		"exportLevelBg":       true,
+		"pngFilePattern":      nil,
+		"backupOnSave":        false,
+		"backupLimit":         10,
+		"backupRelPath":       nil,
+		"levelNamePattern":    "Level_%idx",
+		"tutorialDesc":        nil,
+		"customCommands":      []any{},
+		"flags":               []any{},
+		"dummyWorldIid":       ldtkIID(w.Seed, "world"),
+		"worlds":              []any{},
+		"levels":              levels,
+		"defs": ldtkObject{
+			"layers":        []ldtkObject{ldtkLayerDef(opts.GridSize, values, groups)},
+			"entities":      []any{},
+			"tilesets":      []any{},
+			"enums":         []any{},
+			"externalEnums": []any{},
+			"levelFields":   fieldDefs,
+		},
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "\t")
+	return enc.Encode(project)
+}
+
+func SaveLDtk(path string, w *world.World, opts LDtkOptions) error {
+	return saveFile(path, func(out io.Writer) error {
+		return WriteLDtk(out, w, opts)
+	})
+}
+
+// ldtkIntGridValues строит значения IntGrid и группирует их по Name биома,
+// чтобы правила автослоёв можно было писать сразу на всю группу ("Liquid", "Mounts").
+func ldtkIntGridValues(w *world.World, m TileMapping) ([]ldtkObject, []ldtkObject) {
+	ids, byID := m.tiles(w)
+
+	groupUIDs := make(map[string]int)
+	var groups []ldtkObject
+	var values []ldtkObject
+	used := make(map[string]bool)
+
+	for _, id := range ids {
+		b := byID[id]
+
+		group := ldtkIdentifier(b.Name)
+		if _, ok := groupUIDs[group]; !ok {
+			groupUIDs[group] = len(groups) + 1
+			groups = append(groups, ldtkObject{"uid": len(groups) + 1, "identifier": group, "color": nil})
+		}
+
+		// Идентификаторы значений в LDtk уникальны, а у полос высот совпадают Name
+		identifier := group
+		for n := 2; used[identifier]; n++ {
+			identifier = fmt.Sprintf("%s_%d", group, n)
+		}
+		used[identifier] = true
+
+		values = append(values, ldtkObject{
+			"value":      id + 1,
+			"identifier": identifier,
+			"color":      strings.ToUpper(b.Color),
+			"tile":       nil,
+			"groupUid":   groupUIDs[group],
+		})
+	}
+
+	return values, groups
+}
+
+func ldtkLayerDef(gridSize int, values, groups []ldtkObject) ldtkObject {
+	return ldtkObject{
+		"__type":                         "IntGrid",
+		"identifier":                     "Biomes",
+		"type":                           "IntGrid",
+		"uid":                            ldtkLayerUID,
+		"doc":                            nil,
+		"uiColor":                        nil,
+		"gridSize":                       gridSize,
+		"guideGridWid":                   0,
+		"guideGridHei":                   0,
+		"displayOpacity":                 1,
+		"inactiveOpacity":                1,
+		"hideInList":                     false,
+		"hideFieldsWhenInactive":         false,
+		"canSelectWhenInactive":          true,
+		"renderInWorldView":              true,
+		"pxOffsetX":                      0,
+		"pxOffsetY":                      0,
+		"parallaxFactorX":                0,
+		"parallaxFactorY":                0,
+		"parallaxScaling":                true,
+		"requiredTags":                   []any{},
+		"excludedTags":                   []any{},
+		"autoTilesKilledByOtherLayerUid": nil,
+		"uiFilterTags":                   []any{},
+		"useAsyncRender":                 false,
+		"intGridValues":                  values,
+		"intGridValuesGroups":            groups,
+		"autoRuleGroups":                 []any{},
+		"autoSourceLayerDefUid":          nil,
+		"tilesetDefUid":                  nil,
+		"tilePivotX":                     0,
+		"tilePivotY":                     0,
+		"biomeFieldUid":                  nil,
+	}
+}
+
+func ldtkFieldDef(uid int, f ldtkField) ldtkObject {
+	return ldtkObject{
+		"identifier":           f.identifier,
+		"doc":                  nil,
+		"__type":               f.kind,
+		"uid":                  uid,
+		"type":                 "F_" + f.kind,
+		"isArray":              false,
+		"canBeNull":            false,
+		"arrayMinLength":       nil,
+		"arrayMaxLength":       nil,
+		"editorDisplayMode":    "Hidden",
+		"editorDisplayScale":   1,
+		"editorDisplayPos":     "Above",
+		"editorLinkStyle":      "StraightArrow",
+		"editorDisplayColor":   nil,
+		"editorAlwaysShow":     false,
+		"editorShowInWorld":    true,
+		"editorCutLongValues":  true,
+		"editorTextSuffix":     nil,
+		"editorTextPrefix":     nil,
+		"useForSmartColor":     false,
+		"exportToToc":          false,
+		"searchable":           false,
+		"min":                  nil,
+		"max":                  nil,
+		"regex":                nil,
+		"acceptFileTypes":      nil,
+		"defaultOverride":      nil,
+		"textLanguageMode":     nil,
+		"symmetricalRef":       false,
+		"autoChainRef":         true,
+		"allowOutOfLevelRef":   true,
+		"allowedRefs":          "OnlySame",
+		"allowedRefsEntityUid": nil,
+		"allowedRefTags":       []any{},
+		"tilesetUid":           nil,
+	}
+}
+
+func ldtkFieldInstance(uid int, f ldtkField) ldtkObject {
+	return ldtkObject{
+		"__identifier": f.identifier,
+		"__type":       f.kind,
+		"__value":      f.value,
+		"__tile":       nil,
+		"defUid":       uid,
+		"realEditorValues": []ldtkObject{{
+			"id":     "V_" + f.kind,
+			"params": []any{f.value},
+		}},
+	}
+}
+
+func ldtkIdentifier(name string) string {
+	id := ldtkIdentifierPattern.ReplaceAllString(name, "_")
+	if id == "" || (id[0] >= '0' && id[0] <= '9') {
+		id = "Biome_" + id
+	}
+	return id
+}
+
+// ldtkIID выдаёт стабильный UUID по сиду и имени объекта, чтобы повторный
+// экспорт того же мира не менял файл.
+func ldtkIID(seed int, name string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d/%s", seed, name)))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"tilemap-generator/mapgen/generator"
+	"tilemap-generator/mapgen/world"
+)
+
+func TestWriteLDtkSplitsChunksIntoLevels(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteLDtk(&buf, testWorld(), LDtkOptions{
+		ChunkSize: 2,
+		Params:    &generator.WorldGeneratorParams{Frequency: 0.01},
+	})
+	if err != nil {
+		t.Fatalf("WriteLDtk: %v", err)
+	}
+
+	var project struct {
+		Levels []struct {
+			Identifier     string `json:"identifier"`
+			WorldX         int    `json:"worldX"`
+			FieldInstances []struct {
+				Identifier string `json:"__identifier"`
+				Value      any    `json:"__value"`
+			} `json:"fieldInstances"`
+			LayerInstances []struct {
+				IntGridCsv []int `json:"intGridCsv"`
+			} `json:"layerInstances"`
+		} `json:"levels"`
+		Defs struct {
+			Layers []struct {
+				IntGridValues []struct {
+					Value      int    `json:"value"`
+					Identifier string `json:"identifier"`
+				} `json:"intGridValues"`
+			} `json:"layers"`
+		} `json:"defs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &project); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if len(project.Levels) != 2 {
+		t.Fatalf("got %d levels, want 2", len(project.Levels))
+	}
+	if got := project.Levels[0].LayerInstances[0].IntGridCsv; !equalInts(got, []int{1, 2, 3, 3}) {
+		t.Errorf("first level csv = %v", got)
+	}
+	if got := project.Levels[1].LayerInstances[0].IntGridCsv; !equalInts(got, []int{3, 1}) {
+		t.Errorf("second level csv = %v", got)
+	}
+	if project.Levels[1].WorldX != 32 {
+		t.Errorf("second level worldX = %d, want 32", project.Levels[1].WorldX)
+	}
+	if f := project.Levels[0].FieldInstances[0]; f.Identifier != "seed" || f.Value != float64(7) {
+		t.Errorf("first field = %+v, want seed=7", f)
+	}
+
+	values := project.Defs.Layers[0].IntGridValues
+	if len(values) != 3 || values[0].Identifier != "Liquid" || values[1].Identifier != "Liquid_2" {
+		t.Errorf("unexpected IntGrid values %+v", values)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWriteLDtkRejectsEmptyWorld(t *testing.T) {
+	if err := WriteLDtk(&bytes.Buffer{}, &world.World{Seed: 1}, LDtkOptions{}); err == nil {
+		t.Fatal("expected an error for an empty world")
+	}
+}
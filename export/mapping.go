@@ -0,0 +1,92 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+// TileMapping сопоставляет биомам номера тайлов в тайлсете (с нуля).
+// Ключ ищется сначала по NameRU (он уникален для каждой полосы высот),
+// затем по Name — так можно одним тайлом покрыть всю группу "Liquid".
+type TileMapping struct {
+	Tiles   map[string]int
+	Default int
+}
+
+// PaletteMapping выдаёт биомам мира номера по порядку их появления,
+// совпадающие с раскладкой image.CreatePaletteTileset.
+func PaletteMapping(w *world.World) TileMapping {
+	m := TileMapping{Tiles: make(map[string]int)}
+	for i, b := range w.Palette() {
+		m.Tiles[mappingKey(b)] = i
+	}
+	return m
+}
+
+func (m TileMapping) TileID(b biome.Data) int {
+	if id, ok := lookup(m.Tiles, b); ok {
+		return id
+	}
+	return m.Default
+}
+
+// lookup ищет биом в словаре сначала по NameRU, затем по Name.
+func lookup[V any](m map[string]V, b biome.Data) (V, bool) {
+	if v, ok := m[b.NameRU]; ok && b.NameRU != "" {
+		return v, true
+	}
+	if v, ok := m[b.Name]; ok && b.Name != "" {
+		return v, true
+	}
+	var zero V
+	return zero, false
+}
+
+// tiles возвращает для каждого используемого номера тайла первый биом,
+// который на него отображается, в порядке палитры мира.
+func (m TileMapping) tiles(w *world.World) ([]int, map[int]biome.Data) {
+	var ids []int
+	byID := make(map[int]biome.Data)
+	for _, b := range w.Palette() {
+		id := m.TileID(b)
+		if _, ok := byID[id]; !ok {
+			ids = append(ids, id)
+			byID[id] = b
+		}
+	}
+	return ids, byID
+}
+
+func mappingKey(b biome.Data) string {
+	if b.NameRU != "" {
+		return b.NameRU
+	}
+	return b.Name
+}
+
+// ErrInvalid отличает ошибки из-за самого мира или параметров экспорта от ошибок
+// записи файла: errors.Is(err, ErrInvalid).
+var ErrInvalid = errors.New("export: invalid input")
+
+type invalidError struct{ msg string }
+
+func (e *invalidError) Error() string        { return e.msg }
+func (e *invalidError) Is(target error) bool { return target == ErrInvalid }
+
+func invalidf(format string, args ...any) error {
+	return &invalidError{fmt.Sprintf(format, args...)}
+}
+
+func saveFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return write(file)
+}
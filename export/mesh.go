@@ -0,0 +1,293 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"tilemap-generator/mapgen/world"
+)
+
+var ErrNoHeights error = &invalidError{"export: world has no height layer"}
+
+type MeshOptions struct {
+	// Размер клетки по горизонтали в единицах сцены. Default: 1.
+	CellSize float64
+	// Высота рельефа при высоте 1.0 в тех же единицах. Default: 32.
+	VerticalScale float64
+	// Прореживание: вершина ставится в каждую Step-ю клетку. Default: 1.
+	Step int
+}
+
+// Mesh — треугольная сетка рельефа. Ось Y направлена вверх, X — вдоль ширины
+// мира, Z — вдоль высоты; треугольники обходятся против часовой стрелки при взгляде сверху.
+type Mesh struct {
+	Positions [][3]float32
+	Normals   [][3]float32
+	// Цвета вершин в sRGB, 0..1.
+	Colors  [][3]float32
+	Indices []uint32
+}
+
+func BuildMesh(w *world.World, opts MeshOptions) (*Mesh, error) {
+	if !w.HasHeights() {
+		return nil, ErrNoHeights
+	}
+	if opts.CellSize == 0 {
+		opts.CellSize = 1
+	}
+	if opts.VerticalScale == 0 {
+		opts.VerticalScale = 32
+	}
+	if opts.Step < 1 {
+		opts.Step = 1
+	}
+
+	// Последняя строка и столбец берутся всегда, чтобы сетка не обрезалась при прореживании
+	xs := sampleAxis(w.Width, opts.Step)
+	zs := sampleAxis(w.Height, opts.Step)
+	if len(xs) < 2 || len(zs) < 2 {
+		return nil, invalidf("export: world %dx%d is too small for a mesh", w.Width, w.Height)
+	}
+	if len(xs)*len(zs) > math.MaxUint32 {
+		return nil, invalidf("export: mesh would have too many vertices, increase Step")
+	}
+
+	m := &Mesh{
+		Positions: make([][3]float32, 0, len(xs)*len(zs)),
+		Normals:   make([][3]float32, 0, len(xs)*len(zs)),
+		Colors:    make([][3]float32, 0, len(xs)*len(zs)),
+		Indices:   make([]uint32, 0, (len(xs)-1)*(len(zs)-1)*6),
+	}
+
+	height := func(i, j int) float64 {
+		i = min(max(i, 0), len(xs)-1)
+		j = min(max(j, 0), len(zs)-1)
+		return w.Heights[zs[j]][xs[i]] * opts.VerticalScale
+	}
+	pos := func(i int, axis []int64) float64 {
+		i = min(max(i, 0), len(axis)-1)
+		return float64(axis[i]) * opts.CellSize
+	}
+
+	for j, z := range zs {
+		for i, x := range xs {
+			m.Positions = append(m.Positions, [3]float32{
+				float32(float64(x) * opts.CellSize),
+				float32(height(i, j)),
+				float32(float64(z) * opts.CellSize),
+			})
+
+			// Нормаль по центральным разностям соседних вершин сетки
+			dx := (height(i+1, j) - height(i-1, j)) / (pos(i+1, xs) - pos(i-1, xs))
+			dz := (height(i, j+1) - height(i, j-1)) / (pos(j+1, zs) - pos(j-1, zs))
+			m.Normals = append(m.Normals, normalize(-dx, 1, -dz))
+
+			r, g, b, err := parseColor(w.Matrix[z][x].Color)
+			if err != nil {
+				r, g, b = 0.5, 0.5, 0.5
+			}
+			m.Colors = append(m.Colors, [3]float32{r, g, b})
+		}
+	}
+
+	cols := uint32(len(xs))
+	for j := uint32(0); j+1 < uint32(len(zs)); j++ {
+		for i := uint32(0); i+1 < cols; i++ {
+			a := j*cols + i
+			b, c, d := a+1, a+cols, a+cols+1
+			m.Indices = append(m.Indices, a, c, b, b, c, d)
+		}
+	}
+
+	return m, nil
+}
+
+func sampleAxis(size int64, step int) []int64 {
+	var axis []int64
+	for v := int64(0); v < size; v += int64(step) {
+		axis = append(axis, v)
+	}
+	if len(axis) > 0 && axis[len(axis)-1] != size-1 {
+		axis = append(axis, size-1)
+	}
+	return axis
+}
+
+func normalize(x, y, z float64) [3]float32 {
+	l := math.Sqrt(x*x + y*y + z*z)
+	if l == 0 {
+		return [3]float32{0, 1, 0}
+	}
+	return [3]float32{float32(x / l), float32(y / l), float32(z / l)}
+}
+
+func parseColor(hex string) (float32, float32, float32, error) {
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(hex, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return 0, 0, 0, err
+	}
+	return float32(r) / 255, float32(g) / 255, float32(b) / 255, nil
+}
+
+// WriteOBJ пишет Wavefront OBJ с цветом вершин в расширении "v x y z r g b",
+// которое понимают Blender и MeshLab.
+func WriteOBJ(out io.Writer, m *Mesh) error {
+	bw := bufio.NewWriter(out)
+	fmt.Fprintf(bw, "# tilemap-generator terrain: %d vertices, %d triangles\n", len(m.Positions), len(m.Indices)/3)
+	for i, p := range m.Positions {
+		c := m.Colors[i]
+		fmt.Fprintf(bw, "v %g %g %g %.4f %.4f %.4f\n", p[0], p[1], p[2], c[0], c[1], c[2])
+	}
+	for _, n := range m.Normals {
+		fmt.Fprintf(bw, "vn %.5f %.5f %.5f\n", n[0], n[1], n[2])
+	}
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		a, b, c := m.Indices[i]+1, m.Indices[i+1]+1, m.Indices[i+2]+1
+		fmt.Fprintf(bw, "f %d//%d %d//%d %d//%d\n", a, a, b, b, c, c)
+	}
+	return bw.Flush()
+}
+
+// WriteSTL пишет бинарный STL. Цвета в STL не переносятся: формат для печати.
+func WriteSTL(out io.Writer, m *Mesh) error {
+	bw := bufio.NewWriter(out)
+	le := binary.LittleEndian
+
+	var header [80]byte
+	copy(header[:], "tilemap-generator terrain")
+	bw.Write(header[:])
+	binary.Write(bw, le, uint32(len(m.Indices)/3))
+
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		a, b, c := m.Positions[m.Indices[i]], m.Positions[m.Indices[i+1]], m.Positions[m.Indices[i+2]]
+		u := [3]float64{float64(b[0] - a[0]), float64(b[1] - a[1]), float64(b[2] - a[2])}
+		v := [3]float64{float64(c[0] - a[0]), float64(c[1] - a[1]), float64(c[2] - a[2])}
+		n := normalize(u[1]*v[2]-u[2]*v[1], u[2]*v[0]-u[0]*v[2], u[0]*v[1]-u[1]*v[0])
+
+		binary.Write(bw, le, n)
+		binary.Write(bw, le, a)
+		binary.Write(bw, le, b)
+		binary.Write(bw, le, c)
+		binary.Write(bw, le, uint16(0))
+	}
+	return bw.Flush()
+}
+
+const (
+	glbMagic      = 0x46546C67 // "glTF"
+	glbChunkJSON  = 0x4E4F534A // "JSON"
+	glbChunkBIN   = 0x004E4942 // "BIN\0"
+	glArrayBuffer = 34962
+	glElementsBuf = 34963
+	glFloat       = 5126
+	glUnsignedInt = 5125
+)
+
+// WriteGLB пишет glTF 2.0 в бинарном контейнере: один узел с одной сеткой,
+// атрибуты POSITION, NORMAL и COLOR_0 и индексы uint32.
+func WriteGLB(out io.Writer, m *Mesh) error {
+	var bin bytes.Buffer
+	le := binary.LittleEndian
+
+	minPos := [3]float32{float32(math.Inf(1)), float32(math.Inf(1)), float32(math.Inf(1))}
+	maxPos := [3]float32{float32(math.Inf(-1)), float32(math.Inf(-1)), float32(math.Inf(-1))}
+	for _, p := range m.Positions {
+		for k := 0; k < 3; k++ {
+			minPos[k] = min(minPos[k], p[k])
+			maxPos[k] = max(maxPos[k], p[k])
+		}
+	}
+
+	// glTF ожидает линейные цвета вершин, а биомы заданы в sRGB
+	linear := make([][3]float32, len(m.Colors))
+	for i, c := range m.Colors {
+		linear[i] = [3]float32{srgbToLinear(c[0]), srgbToLinear(c[1]), srgbToLinear(c[2])}
+	}
+
+	type view struct{ offset, length, target int }
+	var views []view
+	appendView := func(data any, target int) {
+		offset := bin.Len()
+		binary.Write(&bin, le, data)
+		views = append(views, view{offset, bin.Len() - offset, target})
+	}
+	appendView(m.Positions, glArrayBuffer)
+	appendView(m.Normals, glArrayBuffer)
+	appendView(linear, glArrayBuffer)
+	appendView(m.Indices, glElementsBuf)
+
+	bufferViews := make([]map[string]any, len(views))
+	for i, v := range views {
+		bufferViews[i] = map[string]any{"buffer": 0, "byteOffset": v.offset, "byteLength": v.length, "target": v.target}
+	}
+
+	count := len(m.Positions)
+	doc := map[string]any{
+		"asset":  map[string]any{"version": "2.0", "generator": "tilemap-generator"},
+		"scene":  0,
+		"scenes": []any{map[string]any{"nodes": []int{0}}},
+		"nodes":  []any{map[string]any{"mesh": 0, "name": "terrain"}},
+		"meshes": []any{map[string]any{
+			"name": "terrain",
+			"primitives": []any{map[string]any{
+				"attributes": map[string]int{"POSITION": 0, "NORMAL": 1, "COLOR_0": 2},
+				"indices":    3,
+				"mode":       4,
+			}},
+		}},
+		"accessors": []any{
+			map[string]any{"bufferView": 0, "componentType": glFloat, "count": count, "type": "VEC3", "min": minPos, "max": maxPos},
+			map[string]any{"bufferView": 1, "componentType": glFloat, "count": count, "type": "VEC3"},
+			map[string]any{"bufferView": 2, "componentType": glFloat, "count": count, "type": "VEC3"},
+			map[string]any{"bufferView": 3, "componentType": glUnsignedInt, "count": len(m.Indices), "type": "SCALAR"},
+		},
+		"bufferViews": bufferViews,
+		"buffers":     []any{map[string]any{"byteLength": bin.Len()}},
+	}
+
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	// Чанки выравниваются на 4 байта: JSON пробелами, бинарный — нулями
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+	for bin.Len()%4 != 0 {
+		bin.WriteByte(0)
+	}
+
+	bw := bufio.NewWriter(out)
+	total := 12 + 8 + len(js) + 8 + bin.Len()
+	binary.Write(bw, le, [3]uint32{glbMagic, 2, uint32(total)})
+	binary.Write(bw, le, [2]uint32{uint32(len(js)), glbChunkJSON})
+	bw.Write(js)
+	binary.Write(bw, le, [2]uint32{uint32(bin.Len()), glbChunkBIN})
+	bw.Write(bin.Bytes())
+
+	return bw.Flush()
+}
+
+func srgbToLinear(c float32) float32 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return float32(math.Pow((float64(c)+0.055)/1.055, 2.4))
+}
+
+func SaveOBJ(path string, m *Mesh) error {
+	return saveFile(path, func(out io.Writer) error { return WriteOBJ(out, m) })
+}
+
+func SaveSTL(path string, m *Mesh) error {
+	return saveFile(path, func(out io.Writer) error { return WriteSTL(out, m) })
+}
+
+func SaveGLB(path string, m *Mesh) error {
+	return saveFile(path, func(out io.Writer) error { return WriteGLB(out, m) })
+}
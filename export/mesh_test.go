@@ -0,0 +1,85 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+func meshWorld() *world.World {
+	matrix := make([][]biome.Data, 5)
+	heights := make([][]float64, 5)
+	for y := range matrix {
+		matrix[y] = []biome.Data{water, water, land, land, land}
+		heights[y] = []float64{0, 0.1, 0.4, 0.8, 1}
+	}
+	return world.NewWorldWithHeights(matrix, heights, 3)
+}
+
+func TestBuildMeshDecimation(t *testing.T) {
+	m, err := BuildMesh(meshWorld(), MeshOptions{Step: 2, VerticalScale: 10})
+	if err != nil {
+		t.Fatalf("BuildMesh: %v", err)
+	}
+
+	// Шаг 2 на 5 клетках даёт столбцы 0, 2, 4
+	if len(m.Positions) != 9 || len(m.Indices) != 2*2*6 {
+		t.Fatalf("got %d vertices and %d indices", len(m.Positions), len(m.Indices))
+	}
+	if p := m.Positions[8]; p != [3]float32{4, 10, 4} {
+		t.Errorf("last vertex = %v, want [4 10 4]", p)
+	}
+	// Рельеф поднимается вдоль X, значит нормаль наклонена в сторону -X
+	if n := m.Normals[4]; n[0] >= 0 || n[1] <= 0 {
+		t.Errorf("normal at center = %v, want tilted to -X", n)
+	}
+}
+
+func TestWriteGLBLayout(t *testing.T) {
+	m, err := BuildMesh(meshWorld(), MeshOptions{})
+	if err != nil {
+		t.Fatalf("BuildMesh: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGLB(&buf, m); err != nil {
+		t.Fatalf("WriteGLB: %v", err)
+	}
+	data := buf.Bytes()
+
+	le := binary.LittleEndian
+	if le.Uint32(data[0:]) != glbMagic || le.Uint32(data[4:]) != 2 || int(le.Uint32(data[8:])) != len(data) {
+		t.Fatalf("bad GLB header % x", data[:12])
+	}
+	jsonLen := le.Uint32(data[12:])
+	var doc struct {
+		Accessors []struct {
+			Count int `json:"count"`
+		} `json:"accessors"`
+	}
+	if err := json.Unmarshal(data[20:20+jsonLen], &doc); err != nil {
+		t.Fatalf("GLB JSON chunk: %v", err)
+	}
+	if doc.Accessors[0].Count != 25 || doc.Accessors[3].Count != 4*4*6 {
+		t.Errorf("unexpected accessor counts %+v", doc.Accessors)
+	}
+}
+
+func TestWriteSTLSize(t *testing.T) {
+	m, err := BuildMesh(meshWorld(), MeshOptions{})
+	if err != nil {
+		t.Fatalf("BuildMesh: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSTL(&buf, m); err != nil {
+		t.Fatalf("WriteSTL: %v", err)
+	}
+	triangles := len(m.Indices) / 3
+	if buf.Len() != 84+50*triangles {
+		t.Errorf("STL size = %d, want %d", buf.Len(), 84+50*triangles)
+	}
+}
@@ -0,0 +1,337 @@
+package export
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+const (
+	tiledVersion       = "1.10"
+	tiledEditorVersion = "1.10.2"
+)
+
+type TiledTileset struct {
+	Name                    string
+	Image                   string
+	ImageWidth, ImageHeight int
+	TileWidth, TileHeight   int
+	Columns                 int
+}
+
+type TiledOptions struct {
+	Tileset TiledTileset
+	// Если Mapping.Tiles пуст, используется PaletteMapping.
+	Mapping TileMapping
+}
+
+func (o TiledOptions) withDefaults(w *world.World) TiledOptions {
+	if len(o.Mapping.Tiles) == 0 {
+		o.Mapping = PaletteMapping(w)
+	}
+	ts := &o.Tileset
+	if ts.Name == "" {
+		ts.Name = "biomes"
+	}
+	if ts.Image == "" {
+		// Картинку с такой раскладкой рисует image.CreatePaletteTileset
+		ts.Image = ts.Name + ".png"
+	}
+	if ts.TileWidth == 0 {
+		ts.TileWidth = 16
+	}
+	if ts.TileHeight == 0 {
+		ts.TileHeight = ts.TileWidth
+	}
+	if ts.Columns == 0 {
+		ts.Columns = len(o.Mapping.Tiles)
+		if ts.ImageWidth > 0 {
+			ts.Columns = ts.ImageWidth / ts.TileWidth
+		}
+	}
+	if ts.ImageWidth == 0 {
+		ts.ImageWidth = ts.Columns * ts.TileWidth
+	}
+	if ts.ImageHeight == 0 {
+		ts.ImageHeight = ts.TileHeight * ((o.tileCount() + ts.Columns - 1) / max(ts.Columns, 1))
+	}
+	return o
+}
+
+func (o TiledOptions) tileCount() int {
+	count := o.Mapping.Default + 1
+	for _, id := range o.Mapping.Tiles {
+		count = max(count, id+1)
+	}
+	return count
+}
+
+type tiledProperty struct {
+	XMLName xml.Name `xml:"property" json:"-"`
+	Name    string   `xml:"name,attr" json:"name"`
+	Type    string   `xml:"type,attr,omitempty" json:"type"`
+	Value   any      `xml:"value,attr" json:"value"`
+}
+
+type tiledTile struct {
+	ID         int             `xml:"id,attr" json:"id"`
+	Properties []tiledProperty `xml:"properties>property" json:"properties"`
+}
+
+// mapProperties кладёт в свойства карты сид и названия биомов по номерам тайлов.
+func mapProperties(w *world.World, m TileMapping) ([]tiledProperty, []tiledTile) {
+	ids, byID := m.tiles(w)
+	sort.Ints(ids)
+
+	names := make([]string, 0, len(ids))
+	tiles := make([]tiledTile, 0, len(ids))
+	for _, id := range ids {
+		b := byID[id]
+		names = append(names, b.NameRU)
+		tiles = append(tiles, tiledTile{
+			ID: id,
+			Properties: []tiledProperty{
+				{Name: "name", Type: "string", Value: b.Name},
+				{Name: "name_ru", Type: "string", Value: b.NameRU},
+				{Name: "color", Type: "color", Value: "#ff" + strings.TrimPrefix(b.Color, "#")},
+			},
+		})
+	}
+
+	props := []tiledProperty{
+		{Name: "biomes", Type: "string", Value: strings.Join(names, ",")},
+		{Name: "seed", Type: "int", Value: w.Seed},
+	}
+	return props, tiles
+}
+
+// gids — номера тайлов слоя построчно; firstgid тайлсета всегда 1.
+func gids(w *world.World, m TileMapping) []int {
+	data := make([]int, 0, w.Width*w.Height)
+	w.Each(func(_ world.Point, b biome.Data) bool {
+		data = append(data, m.TileID(b)+1)
+		return true
+	})
+	return data
+}
+
+type tmxMap struct {
+	XMLName      xml.Name        `xml:"map"`
+	Version      string          `xml:"version,attr"`
+	TiledVersion string          `xml:"tiledversion,attr"`
+	Orientation  string          `xml:"orientation,attr"`
+	RenderOrder  string          `xml:"renderorder,attr"`
+	Width        int64           `xml:"width,attr"`
+	Height       int64           `xml:"height,attr"`
+	TileWidth    int             `xml:"tilewidth,attr"`
+	TileHeight   int             `xml:"tileheight,attr"`
+	Infinite     int             `xml:"infinite,attr"`
+	NextLayerID  int             `xml:"nextlayerid,attr"`
+	NextObjectID int             `xml:"nextobjectid,attr"`
+	Properties   []tiledProperty `xml:"properties>property"`
+	Tileset      tmxTileset      `xml:"tileset"`
+	Layer        tmxLayer        `xml:"layer"`
+}
+
+type tmxTileset struct {
+	FirstGID   int         `xml:"firstgid,attr"`
+	Name       string      `xml:"name,attr"`
+	TileWidth  int         `xml:"tilewidth,attr"`
+	TileHeight int         `xml:"tileheight,attr"`
+	TileCount  int         `xml:"tilecount,attr"`
+	Columns    int         `xml:"columns,attr"`
+	Image      tmxImage    `xml:"image"`
+	Tiles      []tiledTile `xml:"tile"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+type tmxLayer struct {
+	ID     int     `xml:"id,attr"`
+	Name   string  `xml:"name,attr"`
+	Width  int64   `xml:"width,attr"`
+	Height int64   `xml:"height,attr"`
+	Data   tmxData `xml:"data"`
+}
+
+type tmxData struct {
+	Encoding string `xml:"encoding,attr"`
+	CSV      string `xml:",innerxml"` // только цифры и запятые, экранировать нечего
+}
+
+func WriteTMX(out io.Writer, w *world.World, opts TiledOptions) error {
+	opts = opts.withDefaults(w)
+	props, tiles := mapProperties(w, opts.Mapping)
+
+	// CSV по строке карты на строку файла, как пишет сам Tiled
+	var csv strings.Builder
+	csv.WriteByte('\n')
+	data := gids(w, opts.Mapping)
+	for i, gid := range data {
+		csv.WriteString(strconv.Itoa(gid))
+		if i < len(data)-1 {
+			csv.WriteByte(',')
+		}
+		if int64(i+1)%w.Width == 0 {
+			csv.WriteByte('\n')
+		}
+	}
+
+	ts := opts.Tileset
+	m := tmxMap{
+		Version:      tiledVersion,
+		TiledVersion: tiledEditorVersion,
+		Orientation:  "orthogonal",
+		RenderOrder:  "right-down",
+		Width:        w.Width,
+		Height:       w.Height,
+		TileWidth:    ts.TileWidth,
+		TileHeight:   ts.TileHeight,
+		NextLayerID:  2,
+		NextObjectID: 1,
+		Properties:   props,
+		Tileset: tmxTileset{
+			FirstGID:   1,
+			Name:       ts.Name,
+			TileWidth:  ts.TileWidth,
+			TileHeight: ts.TileHeight,
+			TileCount:  opts.tileCount(),
+			Columns:    ts.Columns,
+			Image:      tmxImage{Source: ts.Image, Width: ts.ImageWidth, Height: ts.ImageHeight},
+			Tiles:      tiles,
+		},
+		Layer: tmxLayer{
+			ID:     1,
+			Name:   "biomes",
+			Width:  w.Width,
+			Height: w.Height,
+			Data:   tmxData{Encoding: "csv", CSV: csv.String()},
+		},
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", " ")
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("export: tmx: %w", err)
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+type tiledJSONMap struct {
+	Type         string             `json:"type"`
+	Version      string             `json:"version"`
+	TiledVersion string             `json:"tiledversion"`
+	Orientation  string             `json:"orientation"`
+	RenderOrder  string             `json:"renderorder"`
+	Width        int64              `json:"width"`
+	Height       int64              `json:"height"`
+	TileWidth    int                `json:"tilewidth"`
+	TileHeight   int                `json:"tileheight"`
+	Infinite     bool               `json:"infinite"`
+	NextLayerID  int                `json:"nextlayerid"`
+	NextObjectID int                `json:"nextobjectid"`
+	Properties   []tiledProperty    `json:"properties"`
+	Tilesets     []tiledJSONTileset `json:"tilesets"`
+	Layers       []tiledJSONLayer   `json:"layers"`
+}
+
+type tiledJSONTileset struct {
+	FirstGID    int         `json:"firstgid"`
+	Name        string      `json:"name"`
+	Image       string      `json:"image"`
+	ImageWidth  int         `json:"imagewidth"`
+	ImageHeight int         `json:"imageheight"`
+	TileWidth   int         `json:"tilewidth"`
+	TileHeight  int         `json:"tileheight"`
+	TileCount   int         `json:"tilecount"`
+	Columns     int         `json:"columns"`
+	Margin      int         `json:"margin"`
+	Spacing     int         `json:"spacing"`
+	Tiles       []tiledTile `json:"tiles"`
+}
+
+type tiledJSONLayer struct {
+	ID      int     `json:"id"`
+	Name    string  `json:"name"`
+	Type    string  `json:"type"`
+	Width   int64   `json:"width"`
+	Height  int64   `json:"height"`
+	X       int     `json:"x"`
+	Y       int     `json:"y"`
+	Opacity float64 `json:"opacity"`
+	Visible bool    `json:"visible"`
+	Data    []int   `json:"data"`
+}
+
+func WriteTiledJSON(out io.Writer, w *world.World, opts TiledOptions) error {
+	opts = opts.withDefaults(w)
+	props, tiles := mapProperties(w, opts.Mapping)
+	ts := opts.Tileset
+
+	m := tiledJSONMap{
+		Type:         "map",
+		Version:      tiledVersion,
+		TiledVersion: tiledEditorVersion,
+		Orientation:  "orthogonal",
+		RenderOrder:  "right-down",
+		Width:        w.Width,
+		Height:       w.Height,
+		TileWidth:    ts.TileWidth,
+		TileHeight:   ts.TileHeight,
+		NextLayerID:  2,
+		NextObjectID: 1,
+		Properties:   props,
+		Tilesets: []tiledJSONTileset{{
+			FirstGID:    1,
+			Name:        ts.Name,
+			Image:       ts.Image,
+			ImageWidth:  ts.ImageWidth,
+			ImageHeight: ts.ImageHeight,
+			TileWidth:   ts.TileWidth,
+			TileHeight:  ts.TileHeight,
+			TileCount:   opts.tileCount(),
+			Columns:     ts.Columns,
+			Tiles:       tiles,
+		}},
+		Layers: []tiledJSONLayer{{
+			ID:      1,
+			Name:    "biomes",
+			Type:    "tilelayer",
+			Width:   w.Width,
+			Height:  w.Height,
+			Opacity: 1,
+			Visible: true,
+			Data:    gids(w, opts.Mapping),
+		}},
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", " ")
+	return enc.Encode(m)
+}
+
+func SaveTMX(path string, w *world.World, opts TiledOptions) error {
+	return saveFile(path, func(out io.Writer) error {
+		return WriteTMX(out, w, opts)
+	})
+}
+
+func SaveTiledJSON(path string, w *world.World, opts TiledOptions) error {
+	return saveFile(path, func(out io.Writer) error {
+		return WriteTiledJSON(out, w, opts)
+	})
+}
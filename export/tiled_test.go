@@ -0,0 +1,70 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+var (
+	water = biome.Data{Name: "Liquid", NameRU: "Океан", Color: "#4292c4"}
+	shore = biome.Data{Name: "Liquid", NameRU: "Лагуна", Color: "#56aade"}
+	land  = biome.Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"}
+)
+
+func testWorld() *world.World {
+	return world.NewWorld([][]biome.Data{
+		{water, shore, land},
+		{land, land, water},
+	}, 7)
+}
+
+func TestWriteTMX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTMX(&buf, testWorld(), TiledOptions{}); err != nil {
+		t.Fatalf("WriteTMX: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		`<data encoding="csv">` + "\n1,2,3,\n3,3,1\n</data>",
+		`<property name="seed" type="int" value="7">`,
+		`<image source="biomes.png" width="48" height="16">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("TMX output is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteTiledJSONGroupsByName(t *testing.T) {
+	mapping := TileMapping{Tiles: map[string]int{"Liquid": 4, "Луга": 2}}
+
+	var buf bytes.Buffer
+	if err := WriteTiledJSON(&buf, testWorld(), TiledOptions{Mapping: mapping}); err != nil {
+		t.Fatalf("WriteTiledJSON: %v", err)
+	}
+
+	var m struct {
+		Layers []struct {
+			Data []int `json:"data"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	want := []int{5, 5, 3, 3, 3, 5}
+	if got := m.Layers[0].Data; len(got) != len(want) {
+		t.Fatalf("data = %v, want %v", got, want)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("data = %v, want %v", got, want)
+			}
+		}
+	}
+}
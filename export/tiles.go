@@ -0,0 +1,316 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+// Экспорт пирамиды тайлов z/x/y.png для веб-просмотрщиков (Leaflet, OpenLayers).
+// На максимальном уровне одна клетка — один пиксель; каждый уровень ниже вдвое
+// уменьшает предыдущий. Уровни выше максимального увеличивают клетки без сглаживания.
+
+type Downsample string
+
+const (
+	// DownsampleMajority — цвет биома, занимающего больше всего клеток блока.
+	DownsampleMajority Downsample = "majority"
+	// DownsampleAverage — средний цвет клеток блока.
+	DownsampleAverage Downsample = "average"
+)
+
+type TileOptions struct {
+	// Сторона тайла в пикселях. Default: 256.
+	TileSize int
+	// Default: DownsampleMajority.
+	Downsample Downsample
+	MinZoom    int
+	// nil — уровень, на котором клетка равна пикселю (NativeZoom).
+	MaxZoom *int
+}
+
+func (o TileOptions) withDefaults(w *world.World) TileOptions {
+	if o.TileSize <= 0 {
+		o.TileSize = 256
+	}
+	if o.Downsample == "" {
+		o.Downsample = DownsampleMajority
+	}
+	if o.MaxZoom == nil {
+		native := NativeZoom(w, o.TileSize)
+		o.MaxZoom = &native
+	}
+	return o
+}
+
+// NativeZoom — наименьший уровень, на котором мир целиком помещается в тайлы
+// при масштабе клетка = пиксель.
+func NativeZoom(w *world.World, tileSize int) int {
+	tiles := float64(max(w.Width, w.Height)) / float64(tileSize)
+	return max(0, int(math.Ceil(math.Log2(tiles))))
+}
+
+type TileLevel struct {
+	Zoom int `json:"zoom"`
+	// Клеток на пиксель; меньше 1 — клетка растянута на несколько пикселей.
+	CellsPerPixel float64 `json:"cells_per_pixel"`
+	TilesX        int     `json:"tiles_x"`
+	TilesY        int     `json:"tiles_y"`
+}
+
+type TileMetadata struct {
+	Format     string     `json:"format"`
+	URL        string     `json:"url"`
+	TileSize   int        `json:"tile_size"`
+	MinZoom    int        `json:"min_zoom"`
+	MaxZoom    int        `json:"max_zoom"`
+	NativeZoom int        `json:"native_zoom"`
+	Downsample Downsample `json:"downsample"`
+	// Границы мира в клетках: [minX, minY, maxX, maxY].
+	Bounds [4]int64    `json:"bounds"`
+	Seed   int         `json:"seed"`
+	Levels []TileLevel `json:"levels"`
+}
+
+type TileStats struct {
+	Written, Skipped int
+}
+
+// SaveTilePyramid пишет dir/z/x/y.png и dir/metadata.json. Тайлы, совпадающие
+// байт в байт с уже лежащими на диске, не перезаписываются, поэтому повторный
+// экспорт после небольшой правки трогает только изменившиеся файлы.
+func SaveTilePyramid(dir string, w *world.World, opts TileOptions) (TileStats, error) {
+	var stats TileStats
+	opts = opts.withDefaults(w)
+	if opts.Downsample != DownsampleMajority && opts.Downsample != DownsampleAverage {
+		return stats, invalidf("export: unknown downsample mode %q", opts.Downsample)
+	}
+	maxZoom := *opts.MaxZoom
+	if opts.MinZoom < 0 || opts.MinZoom > maxZoom {
+		return stats, invalidf("export: invalid zoom range %d..%d", opts.MinZoom, maxZoom)
+	}
+
+	pyramid, err := newTilePyramid(w)
+	if err != nil {
+		return stats, err
+	}
+	native := NativeZoom(w, opts.TileSize)
+
+	meta := TileMetadata{
+		Format:     "png",
+		URL:        "{z}/{x}/{y}.png",
+		TileSize:   opts.TileSize,
+		MinZoom:    opts.MinZoom,
+		MaxZoom:    maxZoom,
+		NativeZoom: native,
+		Downsample: opts.Downsample,
+		Bounds:     [4]int64{0, 0, w.Width, w.Height},
+		Seed:       w.Seed,
+	}
+
+	for z := opts.MinZoom; z <= maxZoom; z++ {
+		level := pyramid.level(max(0, native-z))
+		// На уровнях выше родного одна выборка занимает несколько пикселей
+		pixels := 1 << max(0, z-native)
+		tilesX := (level.width*pixels + opts.TileSize - 1) / opts.TileSize
+		tilesY := (level.height*pixels + opts.TileSize - 1) / opts.TileSize
+		meta.Levels = append(meta.Levels, TileLevel{
+			Zoom:          z,
+			CellsPerPixel: math.Pow(2, float64(native-z)),
+			TilesX:        tilesX,
+			TilesY:        tilesY,
+		})
+
+		for ty := 0; ty < tilesY; ty++ {
+			for tx := 0; tx < tilesX; tx++ {
+				img := level.tile(tx, ty, opts.TileSize, pixels, opts.Downsample, pyramid.colors)
+				path := filepath.Join(dir, strconv.Itoa(z), strconv.Itoa(tx), strconv.Itoa(ty)+".png")
+				written, err := writeIfChanged(path, img)
+				if err != nil {
+					return stats, err
+				}
+				if written {
+					stats.Written++
+				} else {
+					stats.Skipped++
+				}
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return stats, err
+	}
+	return stats, os.WriteFile(filepath.Join(dir, "metadata.json"), append(data, '\n'), 0o644)
+}
+
+func writeIfChanged(path string, img image.Image) (bool, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return false, err
+	}
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, buf.Bytes()) {
+		return false, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// tileLevel — мир, уменьшенный в 2^n раз. Для каждой выборки хранится индекс
+// преобладающего биома, средний цвет и число клеток (у края блоки неполные).
+type tileLevel struct {
+	width, height int
+	index         []uint16
+	rgb           [][3]float32
+	count         []uint32
+}
+
+type tilePyramid struct {
+	colors []color.NRGBA
+	levels []*tileLevel
+}
+
+func newTilePyramid(w *world.World) (*tilePyramid, error) {
+	palette := w.Palette()
+	if len(palette) > math.MaxUint16+1 {
+		return nil, invalidf("export: too many biomes for tiles: %d", len(palette))
+	}
+
+	p := &tilePyramid{colors: make([]color.NRGBA, len(palette))}
+	indices := make(map[biome.Data]uint16, len(palette))
+	for i, b := range palette {
+		r, g, bl, err := parseColor(b.Color)
+		if err != nil {
+			r, g, bl = 0.5, 0.5, 0.5
+		}
+		p.colors[i] = color.NRGBA{uint8(r*255 + 0.5), uint8(g*255 + 0.5), uint8(bl*255 + 0.5), 255}
+		indices[b] = uint16(i)
+	}
+
+	base := &tileLevel{
+		width:  int(w.Width),
+		height: int(w.Height),
+		index:  make([]uint16, w.Width*w.Height),
+		rgb:    make([][3]float32, w.Width*w.Height),
+		count:  make([]uint32, w.Width*w.Height),
+	}
+	for y := 0; y < base.height; y++ {
+		for x := 0; x < base.width; x++ {
+			i := y*base.width + x
+			idx := indices[w.Matrix[y][x]]
+			c := p.colors[idx]
+			base.index[i] = idx
+			base.rgb[i] = [3]float32{float32(c.R), float32(c.G), float32(c.B)}
+			base.count[i] = 1
+		}
+	}
+	p.levels = []*tileLevel{base}
+
+	return p, nil
+}
+
+// level возвращает мир, уменьшенный в 2^n раз, достраивая уровни по необходимости.
+// Каждый уровень строится из предыдущего блоками 2×2 с весами по числу клеток.
+func (p *tilePyramid) level(n int) *tileLevel {
+	for len(p.levels) <= n {
+		prev := p.levels[len(p.levels)-1]
+		next := &tileLevel{width: (prev.width + 1) / 2, height: (prev.height + 1) / 2}
+		size := next.width * next.height
+		next.index = make([]uint16, size)
+		next.rgb = make([][3]float32, size)
+		next.count = make([]uint32, size)
+
+		for y := 0; y < next.height; y++ {
+			for x := 0; x < next.width; x++ {
+				var ids [4]uint16
+				var weights [4]uint32
+				var rgb [3]float64
+				var total uint32
+				k := 0
+
+				for dy := 0; dy < 2; dy++ {
+					for dx := 0; dx < 2; dx++ {
+						cx, cy := 2*x+dx, 2*y+dy
+						if cx >= prev.width || cy >= prev.height {
+							continue
+						}
+						i := cy*prev.width + cx
+						cells := prev.count[i]
+						total += cells
+						for c := 0; c < 3; c++ {
+							rgb[c] += float64(prev.rgb[i][c]) * float64(cells)
+						}
+
+						// Веса одинаковых биомов складываются; при равенстве побеждает первый
+						found := false
+						for j := 0; j < k; j++ {
+							if ids[j] == prev.index[i] {
+								weights[j] += cells
+								found = true
+								break
+							}
+						}
+						if !found {
+							ids[k], weights[k] = prev.index[i], cells
+							k++
+						}
+					}
+				}
+
+				best := 0
+				for j := 1; j < k; j++ {
+					if weights[j] > weights[best] {
+						best = j
+					}
+				}
+
+				i := y*next.width + x
+				next.index[i] = ids[best]
+				next.count[i] = total
+				for c := 0; c < 3; c++ {
+					next.rgb[i][c] = float32(rgb[c] / float64(total))
+				}
+			}
+		}
+		p.levels = append(p.levels, next)
+	}
+	return p.levels[n]
+}
+
+// tile рисует тайл (tx, ty); каждая выборка уровня занимает pixels×pixels пикселей.
+// Часть тайла за краем мира остаётся прозрачной.
+func (l *tileLevel) tile(tx, ty, size, pixels int, mode Downsample, colors []color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for py := 0; py < size; py++ {
+		sy := (ty*size + py) / pixels
+		if sy >= l.height {
+			break
+		}
+		for px := 0; px < size; px++ {
+			sx := (tx*size + px) / pixels
+			if sx >= l.width {
+				break
+			}
+
+			i := sy*l.width + sx
+			c := colors[l.index[i]]
+			if mode == DownsampleAverage {
+				c = color.NRGBA{uint8(l.rgb[i][0] + 0.5), uint8(l.rgb[i][1] + 0.5), uint8(l.rgb[i][2] + 0.5), 255}
+			}
+			img.SetNRGBA(px, py, c)
+		}
+	}
+	return img
+}
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"encoding/json"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+// pixelAt читает пиксель (x, y) тайла с диска.
+func pixelAt(t *testing.T, path string, x, y int) color.NRGBA {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open tile: %v", err)
+	}
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("decode tile: %v", err)
+	}
+	return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+}
+
+func TestSaveTilePyramid(t *testing.T) {
+	// 4×2: в левом блоке 2×2 три клетки воды и одна суша, в правом — только суша
+	w := world.NewWorld([][]biome.Data{
+		{water, water, land, land},
+		{water, land, land, land},
+	}, 9)
+	dir := t.TempDir()
+
+	stats, err := SaveTilePyramid(dir, w, TileOptions{TileSize: 2})
+	if err != nil {
+		t.Fatalf("SaveTilePyramid: %v", err)
+	}
+	// Уровень 1 — два тайла клетка = пиксель, уровень 0 — один уменьшенный тайл
+	if stats.Written != 3 || stats.Skipped != 0 {
+		t.Fatalf("stats = %+v, want 3 written", stats)
+	}
+
+	top := filepath.Join(dir, "0", "0", "0.png")
+	if got := pixelAt(t, top, 0, 0); got != (color.NRGBA{0x42, 0x92, 0xc4, 255}) {
+		t.Errorf("majority pixel = %v, want water color", got)
+	}
+	if got := pixelAt(t, top, 0, 1); got.A != 0 {
+		t.Errorf("pixel outside the world = %v, want transparent", got)
+	}
+
+	var meta TileMetadata
+	data, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		t.Fatalf("metadata: %v", err)
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("metadata: %v", err)
+	}
+	if meta.MaxZoom != 1 || meta.Bounds != [4]int64{0, 0, 4, 2} || len(meta.Levels) != 2 || meta.Levels[1].TilesX != 2 {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+
+	stats, err = SaveTilePyramid(dir, w, TileOptions{TileSize: 2})
+	if err != nil {
+		t.Fatalf("second SaveTilePyramid: %v", err)
+	}
+	if stats.Written != 0 || stats.Skipped != 3 {
+		t.Errorf("re-export stats = %+v, want all skipped", stats)
+	}
+}
+
+func TestSaveTilePyramidZoomZero(t *testing.T) {
+	w := world.NewWorld([][]biome.Data{
+		{water, water, land, land},
+		{water, land, land, land},
+	}, 9)
+	dir := t.TempDir()
+	zero := 0
+
+	// Явный MaxZoom 0 — только верхний уровень, хотя родной уровень мира 1
+	stats, err := SaveTilePyramid(dir, w, TileOptions{TileSize: 2, MaxZoom: &zero})
+	if err != nil {
+		t.Fatalf("SaveTilePyramid: %v", err)
+	}
+	if stats.Written != 1 {
+		t.Errorf("stats = %+v, want 1 written", stats)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1")); !os.IsNotExist(err) {
+		t.Errorf("zoom 1 was written: %v", err)
+	}
+}
+
+func TestSaveTilePyramidAverage(t *testing.T) {
+	w := world.NewWorld([][]biome.Data{
+		{water, land},
+		{water, land},
+	}, 9)
+	dir := t.TempDir()
+	maxZoom := 2
+
+	if _, err := SaveTilePyramid(dir, w, TileOptions{TileSize: 1, Downsample: DownsampleAverage, MaxZoom: &maxZoom}); err != nil {
+		t.Fatalf("SaveTilePyramid: %v", err)
+	}
+
+	got := pixelAt(t, filepath.Join(dir, "0", "0", "0.png"), 0, 0)
+	want := color.NRGBA{(0x42 + 0x5d + 1) / 2, (0x92 + 0xbc) / 2, (0xc4 + 0x21 + 1) / 2, 255}
+	if got != want {
+		t.Errorf("average pixel = %v, want %v", got, want)
+	}
+
+	// Уровень 2 выше родного: клетка растягивается на 2×2 пикселя, тайлов 4×4
+	if _, err := os.Stat(filepath.Join(dir, "2", "3", "3.png")); err != nil {
+		t.Errorf("overzoom tile is missing: %v", err)
+	}
+}
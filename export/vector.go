@@ -0,0 +1,486 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+type VectorOptions struct {
+	// Допуск упрощения Дугласа — Пекера в клетках. 0 — только убрать точки на прямых.
+	// Общая граница двух биомов упрощается один раз, поэтому соседние полигоны
+	// не расходятся; при большом допуске контур может пересечь сам себя.
+	Simplify float64
+	// Размер клетки в единицах вывода. Default: 1.
+	CellSize float64
+}
+
+// Pt — точка в координатах углов клеток: (0, 0) — левый верхний угол мира.
+type Pt struct {
+	X, Y float64
+}
+
+// Polygon — связная область биома. Внешний контур идёт по часовой стрелке
+// на экране (ось Y вниз), дыры — против часовой.
+type Polygon struct {
+	Outer []Pt
+	Holes [][]Pt
+}
+
+type BiomeRegions struct {
+	Biome    biome.Data
+	Polygons []Polygon
+}
+
+// TraceRegions обводит области каждого биома. Контуры строятся marching squares
+// по решётке углов клеток (рёбра между клетками биома и остальными), затем
+// сшиваются в кольца и упрощаются. Клетки, касающиеся только углом, считаются
+// разными областями (4-связность).
+func TraceRegions(w *world.World, opts VectorOptions) ([]BiomeRegions, error) {
+	borders := newBorderSimplifier(w, opts.Simplify)
+	var out []BiomeRegions
+	for _, b := range w.Palette() {
+		rings, err := traceRings(w, b)
+		if err != nil {
+			return nil, err
+		}
+
+		// Дыры раскладываются по контурам без точек на прямых — так дешевле
+		var polys []Polygon
+		var outers, holes, holeRings [][]Pt
+		for _, r := range rings {
+			if ringArea(r) > 0 {
+				polys = append(polys, Polygon{Outer: borders.simplify(r)})
+				outers = append(outers, dropCollinear(r))
+			} else {
+				holes = append(holes, dropCollinear(r))
+				holeRings = append(holeRings, r)
+			}
+		}
+		for i, owner := range holeOwners(outers, holes) {
+			if owner >= 0 {
+				polys[owner].Holes = append(polys[owner].Holes, borders.simplify(holeRings[i]))
+			}
+		}
+		out = append(out, BiomeRegions{Biome: b, Polygons: polys})
+	}
+	return out, nil
+}
+
+type latticeEdge struct {
+	from, to [2]int64
+}
+
+// traceRings возвращает кольца биома со всеми узлами решётки, включая точки на прямых.
+func traceRings(w *world.World, b biome.Data) ([][]Pt, error) {
+	inside := func(x, y int64) bool {
+		return x >= 0 && y >= 0 && x < w.Width && y < w.Height && w.Matrix[y][x] == b
+	}
+
+	// Граничные рёбра, ориентированные так, чтобы клетка биома была справа
+	outgoing := make(map[[2]int64][]latticeEdge)
+	var order [][2]int64
+	add := func(x0, y0, x1, y1 int64) {
+		from := [2]int64{x0, y0}
+		if len(outgoing[from]) == 0 {
+			order = append(order, from)
+		}
+		outgoing[from] = append(outgoing[from], latticeEdge{from, [2]int64{x1, y1}})
+	}
+	w.Each(func(p world.Point, cell biome.Data) bool {
+		if cell != b {
+			return true
+		}
+		x, y := p.X, p.Y
+		if !inside(x, y-1) {
+			add(x, y, x+1, y)
+		}
+		if !inside(x+1, y) {
+			add(x+1, y, x+1, y+1)
+		}
+		if !inside(x, y+1) {
+			add(x+1, y+1, x, y+1)
+		}
+		if !inside(x-1, y) {
+			add(x, y+1, x, y)
+		}
+		return true
+	})
+
+	var rings [][]Pt
+	for _, start := range order {
+		for len(outgoing[start]) > 0 {
+			e := takeEdge(outgoing, start, [2]int64{})
+			ring := []Pt{{float64(e.from[0]), float64(e.from[1])}}
+			// Кольцо замыкается при возврате в начало; если в вершине было касание углом,
+			// оставшиеся рёбра станут отдельным кольцом.
+			for e.to != start {
+				dir := [2]int64{e.to[0] - e.from[0], e.to[1] - e.from[1]}
+				ring = append(ring, Pt{float64(e.to[0]), float64(e.to[1])})
+				if len(outgoing[e.to]) == 0 {
+					return nil, fmt.Errorf("export: contour of %s is not closed at (%d, %d)", b.Name, e.to[0], e.to[1])
+				}
+				e = takeEdge(outgoing, e.to, dir)
+			}
+			rings = append(rings, ring)
+		}
+	}
+	return rings, nil
+}
+
+// takeEdge забирает исходящее ребро из вершины. Если их два (клетки касаются углом),
+// берётся самый правый поворот — так контур огибает одну клетку и не перескакивает
+// на соседнюю по диагонали.
+func takeEdge(outgoing map[[2]int64][]latticeEdge, at [2]int64, dir [2]int64) latticeEdge {
+	edges := outgoing[at]
+	best := 0
+	if len(edges) > 1 && dir != [2]int64{} {
+		right := [2]int64{-dir[1], dir[0]}
+		for i, e := range edges {
+			if [2]int64{e.to[0] - e.from[0], e.to[1] - e.from[1]} == right {
+				best = i
+			}
+		}
+	}
+	e := edges[best]
+	outgoing[at] = append(edges[:best], edges[best+1:]...)
+	return e
+}
+
+// ringArea — удвоенная ориентированная площадь; положительна для обхода
+// по часовой стрелке на экране (ось Y вниз).
+func ringArea(r []Pt) float64 {
+	var a float64
+	for i := range r {
+		j := (i + 1) % len(r)
+		a += r[i].X*r[j].Y - r[j].X*r[i].Y
+	}
+	return a
+}
+
+func dropCollinear(r []Pt) []Pt {
+	out := make([]Pt, 0, len(r))
+	for i := range r {
+		prev := r[(i+len(r)-1)%len(r)]
+		next := r[(i+1)%len(r)]
+		if (r[i].X-prev.X)*(next.Y-r[i].Y)-(r[i].Y-prev.Y)*(next.X-r[i].X) != 0 {
+			out = append(out, r[i])
+		}
+	}
+	return out
+}
+
+// holeOwners находит для каждой дыры наименьший внешний контур, который её содержит
+// (-1, если такого нет). Проверяется точка внутри дыры у середины её первого ребра —
+// она не лежит на сетке.
+func holeOwners(outers, holes [][]Pt) []int {
+	areas := make([]float64, len(outers))
+	for i, r := range outers {
+		areas[i] = ringArea(r)
+	}
+
+	owners := make([]int, len(holes))
+	for k, h := range holes {
+		a, b := h[0], h[1]
+		dx, dy := b.X-a.X, b.Y-a.Y
+		l := math.Hypot(dx, dy)
+		probe := Pt{(a.X+b.X)/2 + 0.25*dy/l, (a.Y+b.Y)/2 - 0.25*dx/l}
+
+		best := -1
+		for i, r := range outers {
+			if (best == -1 || areas[i] < areas[best]) && pointInRing(probe, r) {
+				best = i
+			}
+		}
+		owners[k] = best
+	}
+	return owners
+}
+
+func pointInRing(p Pt, r []Pt) bool {
+	in := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		if (r[i].Y > p.Y) != (r[j].Y > p.Y) &&
+			p.X < (r[j].X-r[i].X)*(p.Y-r[i].Y)/(r[j].Y-r[i].Y)+r[i].X {
+			in = !in
+		}
+	}
+	return in
+}
+
+// borderSimplifier упрощает границы между биомами. Кольцо режется на цепочки
+// между узлами — вершинами, где сходятся больше двух областей или клетки касаются
+// углом. Каждая цепочка упрощается один раз, и соседний биом получает тот же
+// результат в обратном порядке, так что между полигонами не остаётся щелей.
+type borderSimplifier struct {
+	w         *world.World
+	tolerance float64
+	// Упрощённые цепочки по первому ребру, в обоих направлениях
+	chains map[[2]Pt][]Pt
+}
+
+func newBorderSimplifier(w *world.World, tolerance float64) *borderSimplifier {
+	return &borderSimplifier{w: w, tolerance: tolerance, chains: make(map[[2]Pt][]Pt)}
+}
+
+func (s *borderSimplifier) simplify(r []Pt) []Pt {
+	if s.tolerance <= 0 {
+		return dropCollinear(r)
+	}
+
+	var nodes []int
+	for i, p := range r {
+		if s.isNode(p) {
+			nodes = append(nodes, i)
+		}
+	}
+	if len(nodes) == 0 {
+		// Замкнутая граница без узлов: начало выбирается одинаково с обеих сторон
+		start := 0
+		for i, p := range r {
+			if p.Y < r[start].Y || p.Y == r[start].Y && p.X < r[start].X {
+				start = i
+			}
+		}
+		nodes = []int{start}
+	}
+
+	var out []Pt
+	for k, i := range nodes {
+		j := nodes[(k+1)%len(nodes)]
+		if j <= i {
+			j += len(r)
+		}
+		chain := make([]Pt, 0, j-i+1)
+		for n := i; n <= j; n++ {
+			chain = append(chain, r[n%len(r)])
+		}
+		simplified := s.chain(chain)
+		out = append(out, simplified[:len(simplified)-1]...)
+	}
+	return dropCollinear(out)
+}
+
+func (s *borderSimplifier) chain(c []Pt) []Pt {
+	if done, ok := s.chains[[2]Pt{c[0], c[1]}]; ok {
+		return done
+	}
+
+	var out []Pt
+	if c[0] == c[len(c)-1] {
+		out = append(simplifyRing(c[:len(c)-1], s.tolerance), c[0])
+	} else {
+		out = douglasPeucker(c, s.tolerance)
+		// Две цепочки между одними узлами не должны схлопнуться в один отрезок
+		if len(out) == 2 {
+			far, farDist := 0, 0.0
+			for i := 1; i < len(c)-1; i++ {
+				if d := segmentDistance(c[i], c[0], c[len(c)-1]); d > farDist {
+					far, farDist = i, d
+				}
+			}
+			if far > 0 {
+				out = []Pt{c[0], c[far], c[len(c)-1]}
+			}
+		}
+	}
+
+	reversed := make([]Pt, len(out))
+	for i, p := range out {
+		reversed[len(out)-1-i] = p
+	}
+	s.chains[[2]Pt{c[0], c[1]}] = out
+	s.chains[[2]Pt{c[len(c)-1], c[len(c)-2]}] = reversed
+	return out
+}
+
+// isNode сообщает, что у вершины решётки не ровно два граничных ребра.
+// Клетки за краем мира считаются одной областью.
+func (s *borderSimplifier) isNode(p Pt) bool {
+	x, y := int64(p.X), int64(p.Y)
+	cell := func(x, y int64) (biome.Data, bool) {
+		if x < 0 || y < 0 || x >= s.w.Width || y >= s.w.Height {
+			return biome.Data{}, false
+		}
+		return s.w.Matrix[y][x], true
+	}
+	differ := func(x0, y0, x1, y1 int64) bool {
+		a, okA := cell(x0, y0)
+		b, okB := cell(x1, y1)
+		return okA != okB || a != b
+	}
+
+	edges := 0
+	for _, d := range []bool{
+		differ(x-1, y-1, x, y-1), // вверх
+		differ(x-1, y, x, y),     // вниз
+		differ(x-1, y-1, x-1, y), // влево
+		differ(x, y-1, x, y),     // вправо
+	} {
+		if d {
+			edges++
+		}
+	}
+	return edges != 2
+}
+
+// simplifyRing упрощает замкнутый контур по Дугласу — Пекеру, разрезая его
+// в первой и самой дальней от неё точках. Слишком маленькие кольца не трогаются.
+func simplifyRing(r []Pt, tolerance float64) []Pt {
+	if tolerance <= 0 || len(r) < 5 {
+		return r
+	}
+
+	far, farDist := 0, -1.0
+	for i, p := range r {
+		if d := math.Hypot(p.X-r[0].X, p.Y-r[0].Y); d > farDist {
+			far, farDist = i, d
+		}
+	}
+
+	first := douglasPeucker(r[:far+1], tolerance)
+	second := douglasPeucker(append(append([]Pt{}, r[far:]...), r[0]), tolerance)
+	out := append(first[:len(first)-1], second[:len(second)-1]...)
+	if len(out) < 3 {
+		return r
+	}
+	return out
+}
+
+func douglasPeucker(pts []Pt, tolerance float64) []Pt {
+	if len(pts) < 3 {
+		return pts
+	}
+
+	a, b := pts[0], pts[len(pts)-1]
+	idx, maxDist := 0, 0.0
+	for i := 1; i < len(pts)-1; i++ {
+		if d := segmentDistance(pts[i], a, b); d > maxDist {
+			idx, maxDist = i, d
+		}
+	}
+	if maxDist <= tolerance {
+		return []Pt{a, b}
+	}
+
+	left := douglasPeucker(pts[:idx+1], tolerance)
+	right := douglasPeucker(pts[idx:], tolerance)
+	return append(left[:len(left)-1], right...)
+}
+
+func segmentDistance(p, a, b Pt) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lenSq := dx*dx + dy*dy
+	if lenSq == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	t := math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/lenSq))
+	return math.Hypot(p.X-(a.X+t*dx), p.Y-(a.Y+t*dy))
+}
+
+// WriteSVG пишет по одному пути на биом с заливкой его цветом; дыры вырезаются
+// правилом evenodd.
+func WriteSVG(out io.Writer, w *world.World, opts VectorOptions) error {
+	if opts.CellSize == 0 {
+		opts.CellSize = 1
+	}
+	s := opts.CellSize
+	coord := func(v float64) string { return strconv.FormatFloat(v*s, 'f', -1, 64) }
+
+	regions, err := TraceRegions(w, opts)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(out)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\">\n",
+		coord(float64(w.Width)), coord(float64(w.Height)), coord(float64(w.Width)), coord(float64(w.Height)))
+
+	for _, region := range regions {
+		var d strings.Builder
+		for _, p := range region.Polygons {
+			for _, ring := range append([][]Pt{p.Outer}, p.Holes...) {
+				for i, pt := range ring {
+					if i == 0 {
+						d.WriteString("M")
+					} else {
+						d.WriteString(" L")
+					}
+					d.WriteString(coord(pt.X) + " " + coord(pt.Y))
+				}
+				d.WriteString(" Z ")
+			}
+		}
+		fmt.Fprintf(bw, "  <path fill=\"%s\" fill-rule=\"evenodd\" data-name=\"%s\" data-name-ru=\"%s\" d=\"%s\"><title>%s</title></path>\n",
+			html.EscapeString(region.Biome.Color), html.EscapeString(region.Biome.Name), html.EscapeString(region.Biome.NameRU),
+			strings.TrimSpace(d.String()), html.EscapeString(region.Biome.NameRU))
+	}
+
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+// WriteGeoJSON пишет FeatureCollection: по одному MultiPolygon на биом.
+// Ось Y переворачивается (север вверх), а кольца разворачиваются: RFC 7946
+// требует внешние контуры против часовой стрелки и дыры по часовой.
+func WriteGeoJSON(out io.Writer, w *world.World, opts VectorOptions) error {
+	if opts.CellSize == 0 {
+		opts.CellSize = 1
+	}
+	toCoords := func(ring []Pt) [][2]float64 {
+		coords := make([][2]float64, 0, len(ring)+1)
+		for i := len(ring) - 1; i >= 0; i-- {
+			coords = append(coords, [2]float64{ring[i].X * opts.CellSize, (float64(w.Height) - ring[i].Y) * opts.CellSize})
+		}
+		return append(coords, coords[0])
+	}
+
+	regions, err := TraceRegions(w, opts)
+	if err != nil {
+		return err
+	}
+	features := make([]map[string]any, 0)
+	for _, region := range regions {
+		polygons := make([][][][2]float64, 0, len(region.Polygons))
+		for _, p := range region.Polygons {
+			rings := [][][2]float64{toCoords(p.Outer)}
+			for _, h := range p.Holes {
+				rings = append(rings, toCoords(h))
+			}
+			polygons = append(polygons, rings)
+		}
+
+		features = append(features, map[string]any{
+			"type": "Feature",
+			"properties": map[string]any{
+				"name":    region.Biome.Name,
+				"name_ru": region.Biome.NameRU,
+				"color":   region.Biome.Color,
+			},
+			"geometry": map[string]any{
+				"type":        "MultiPolygon",
+				"coordinates": polygons,
+			},
+		})
+	}
+
+	enc := json.NewEncoder(out)
+	return enc.Encode(map[string]any{
+		"type":     "FeatureCollection",
+		"features": features,
+	})
+}
+
+func SaveSVG(path string, w *world.World, opts VectorOptions) error {
+	return saveFile(path, func(out io.Writer) error { return WriteSVG(out, w, opts) })
+}
+
+func SaveGeoJSON(path string, w *world.World, opts VectorOptions) error {
+	return saveFile(path, func(out io.Writer) error { return WriteGeoJSON(out, w, opts) })
+}
@@ -0,0 +1,180 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+func regionsOf(t *testing.T, w *world.World, b biome.Data) []Polygon {
+	t.Helper()
+	regions, err := TraceRegions(w, VectorOptions{})
+	if err != nil {
+		t.Fatalf("TraceRegions: %v", err)
+	}
+	for _, r := range regions {
+		if r.Biome == b {
+			return r.Polygons
+		}
+	}
+	t.Fatalf("no regions for %s", b.NameRU)
+	return nil
+}
+
+func TestTraceRegionsHoles(t *testing.T) {
+	w := world.NewWorld([][]biome.Data{
+		{land, land, land, land},
+		{land, water, water, land},
+		{land, land, land, land},
+	}, 1)
+
+	polys := regionsOf(t, w, land)
+	if len(polys) != 1 {
+		t.Fatalf("land polygons = %d, want 1", len(polys))
+	}
+	if got := len(polys[0].Outer); got != 4 {
+		t.Errorf("outer ring has %d points, want 4: %v", got, polys[0].Outer)
+	}
+	if len(polys[0].Holes) != 1 {
+		t.Fatalf("holes = %d, want 1", len(polys[0].Holes))
+	}
+
+	area := (ringArea(polys[0].Outer) + ringArea(polys[0].Holes[0])) / 2
+	if area != 10 {
+		t.Errorf("land area = %v, want 10", area)
+	}
+}
+
+func TestTraceRegionsDiagonalCellsAreSeparate(t *testing.T) {
+	w := world.NewWorld([][]biome.Data{
+		{land, water},
+		{water, land},
+	}, 1)
+
+	polys := regionsOf(t, w, land)
+	if len(polys) != 2 {
+		t.Fatalf("land polygons = %d, want 2", len(polys))
+	}
+	for _, p := range polys {
+		if ringArea(p.Outer) != 2 || len(p.Holes) != 0 {
+			t.Errorf("unexpected polygon %+v", p)
+		}
+	}
+}
+
+func TestSimplifyRing(t *testing.T) {
+	// Лесенка вдоль диагонали схлопывается при допуске больше полуклетки
+	var ring []Pt
+	for i := 0; i < 5; i++ {
+		ring = append(ring, Pt{float64(i), float64(i)}, Pt{float64(i + 1), float64(i)})
+	}
+	ring = append(ring, Pt{5, 5}, Pt{0, 5})
+
+	got := simplifyRing(ring, 0.8)
+	if len(got) >= len(ring) || len(got) < 3 {
+		t.Fatalf("simplified ring has %d points: %v", len(got), got)
+	}
+	if a := math.Abs(ringArea(got)); math.Abs(a-math.Abs(ringArea(ring))) > 10 {
+		t.Errorf("simplification changed area too much: %v -> %v", ringArea(ring), a)
+	}
+}
+
+func TestTraceRegionsSimplifiedBordersMatch(t *testing.T) {
+	// Круглый остров лагуны внутри океана и ступенчатая граница океана с сушей
+	rows := make([][]biome.Data, 12)
+	for y := range rows {
+		rows[y] = make([]biome.Data, 16)
+		for x := range rows[y] {
+			switch dx, dy := x-4, y-4; {
+			case dx*dx+dy*dy < 10:
+				rows[y][x] = shore
+			case 2*x+y < 22:
+				rows[y][x] = water
+			default:
+				rows[y][x] = land
+			}
+		}
+	}
+	w := world.NewWorld(rows, 1)
+
+	regions, err := TraceRegions(w, VectorOptions{Simplify: 1.5})
+	if err != nil {
+		t.Fatalf("TraceRegions: %v", err)
+	}
+	// Каждая точка мира должна попасть ровно в один полигон: ни щелей, ни наложений
+	for y := 0.13; y < 12; y += 0.25 {
+		for x := 0.11; x < 16; x += 0.25 {
+			covered := 0
+			for _, r := range regions {
+				for _, p := range r.Polygons {
+					in := pointInRing(Pt{x, y}, p.Outer)
+					for _, h := range p.Holes {
+						in = in && !pointInRing(Pt{x, y}, h)
+					}
+					if in {
+						covered++
+					}
+				}
+			}
+			if covered != 1 {
+				t.Fatalf("point (%.2f, %.2f) is covered by %d polygons", x, y, covered)
+			}
+		}
+	}
+}
+
+func TestWriteSVGAndGeoJSON(t *testing.T) {
+	var svg bytes.Buffer
+	if err := WriteSVG(&svg, testWorld(), VectorOptions{CellSize: 10}); err != nil {
+		t.Fatalf("WriteSVG: %v", err)
+	}
+	for _, want := range []string{`viewBox="0 0 30 20"`, `fill="#5dbc21"`, `fill-rule="evenodd"`, `<title>Луга</title>`} {
+		if !strings.Contains(svg.String(), want) {
+			t.Errorf("SVG is missing %q:\n%s", want, svg.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGeoJSON(&buf, testWorld(), VectorOptions{}); err != nil {
+		t.Fatalf("WriteGeoJSON: %v", err)
+	}
+	var fc struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Properties map[string]string `json:"properties"`
+			Geometry   struct {
+				Type        string           `json:"type"`
+				Coordinates [][][][2]float64 `json:"coordinates"`
+			} `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatalf("invalid GeoJSON: %v", err)
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 3 {
+		t.Fatalf("got %s with %d features", fc.Type, len(fc.Features))
+	}
+	for _, f := range fc.Features {
+		if f.Properties["name_ru"] == "" || f.Geometry.Type != "MultiPolygon" {
+			t.Errorf("bad feature %+v", f)
+		}
+		for _, poly := range f.Geometry.Coordinates {
+			outer := poly[0]
+			if outer[0] != outer[len(outer)-1] {
+				t.Errorf("%s: ring is not closed", f.Properties["name_ru"])
+			}
+			// RFC 7946: внешний контур против часовой стрелки (в осях с Y вверх)
+			var a float64
+			for i := 0; i+1 < len(outer); i++ {
+				a += outer[i][0]*outer[i+1][1] - outer[i+1][0]*outer[i][1]
+			}
+			if a <= 0 {
+				t.Errorf("%s: outer ring is clockwise", f.Properties["name_ru"])
+			}
+		}
+	}
+}
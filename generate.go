@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"tilemap-generator/image"
+	"tilemap-generator/mapgen/generator"
+	"tilemap-generator/mapgen/recipe"
+	"tilemap-generator/mapgen/world"
+)
+
+// defaultRecipe — прежние настройки main: мир 1000×1000 с частотой 0.004.
+// Нулевой сид выбирается по времени при генерации.
+func defaultRecipe(biomesPath string) (*recipe.Recipe, error) {
+	set, err := loadBiomes(biomesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return recipe.New(world.Config{Width: 1000, Height: 1000}, generator.WorldGeneratorParams{
+		Frequency: 0.004,
+	}, set), nil
+}
+
+// recipeFlags — рецепт и параметры генерации, общие для generate и batch.
+// Явно заданные флаги применяются поверх рецепта.
+type recipeFlags struct {
+	fs        *flag.FlagSet
+	recipe    string
+	biomes    string
+	width     int64
+	height    int64
+	frequency float64
+	offsetX   int64
+	offsetY   int64
+	noiseType string
+	fractal   string
+	octaves   int
+}
+
+func (f *recipeFlags) register(fs *flag.FlagSet) {
+	f.fs = fs
+	fs.StringVar(&f.recipe, "recipe", "", "recipe to reproduce; other flags override its values")
+	fs.StringVar(&f.biomes, "biomes", "", "biome file (default biomes.json or the built-in table)")
+	fs.Int64Var(&f.width, "width", 1000, "world width in cells")
+	fs.Int64Var(&f.height, "height", 1000, "world height in cells")
+	fs.Float64Var(&f.frequency, "frequency", 0.004, "noise frequency")
+	fs.Int64Var(&f.offsetX, "offset-x", 0, "noise offset along X")
+	fs.Int64Var(&f.offsetY, "offset-y", 0, "noise offset along Y")
+	fs.StringVar(&f.noiseType, "noise", "", "noise type: OpenSimplex2S, OpenSimplex2, Perlin, Cellular, ValueCubic, Value")
+	fs.StringVar(&f.fractal, "fractal", "", "fractal type: None, FBm, Ridged, PingPong")
+	fs.IntVar(&f.octaves, "octaves", 0, "number of fractal octaves")
+}
+
+// load вызывается после разбора флагов.
+func (f *recipeFlags) load() (*recipe.Recipe, error) {
+	var r *recipe.Recipe
+	var err error
+	if f.recipe != "" {
+		if r, err = recipe.Load(f.recipe); err != nil {
+			if os.IsNotExist(err) {
+				return nil, err
+			}
+			return nil, invalidInput(err)
+		}
+	} else if r, err = defaultRecipe(f.biomes); err != nil {
+		return nil, err
+	}
+
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "width":
+			r.Config.Width = f.width
+		case "height":
+			r.Config.Height = f.height
+		case "frequency":
+			r.Params.Frequency = f.frequency
+		case "offset-x":
+			r.Params.OffsetX = f.offsetX
+		case "offset-y":
+			r.Params.OffsetY = f.offsetY
+		case "noise":
+			r.Params.Noise.Type = f.noiseType
+		case "fractal":
+			r.Params.Noise.Fractal = f.fractal
+		case "octaves":
+			r.Params.Noise.Octaves = f.octaves
+		}
+	})
+	if f.recipe != "" && f.biomes != "" {
+		if r.Biomes, err = loadBiomes(f.biomes); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (c *cli) generate(args []string) error {
+	fs := c.flags("generate")
+	var rf recipeFlags
+	rf.register(fs)
+	seed := fs.Int("seed", 0, "noise seed, 0 picks one from the current time")
+	out := fs.String("out", "biome_map.png", "output file")
+	format := fs.String("format", "", "output format: png or tmap (default from the -out extension)")
+	saveRecipe := fs.String("save-recipe", "", "where to save the recipe (default <out>.recipe.json)")
+	noRecipe := fs.Bool("no-recipe", false, "do not save the recipe")
+	heightmap := fs.String("heightmap", "", "heightmap (PNG, .r32 or .r16) to place biomes on instead of noise")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*out)), ".")
+	}
+	if *format != "png" && *format != "tmap" {
+		return usageErrorf("unknown output format %q, want png or tmap", *format)
+	}
+
+	r, err := rf.load()
+	if err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			r.Params.Seed = *seed
+		}
+	})
+	// Путь из флага — от текущего каталога, а не от файла рецепта
+	if *heightmap != "" {
+		if r.Heightmap, err = filepath.Abs(*heightmap); err != nil {
+			return err
+		}
+	}
+
+	w, err := r.Generate()
+	if err != nil {
+		return invalidInput(err)
+	}
+
+	// Рецепт рядом с картинкой позволяет повторить понравившуюся карту,
+	// поэтому в него попадает и выбранный по времени сид
+	if !*noRecipe {
+		r.Params.Seed = w.Seed
+		path := *saveRecipe
+		if path == "" {
+			path = strings.TrimSuffix(*out, filepath.Ext(*out)) + ".recipe.json"
+		}
+		if err := recipe.Save(path, r); err != nil {
+			return fmt.Errorf("saving recipe: %w", err)
+		}
+	}
+
+	if *format == "tmap" {
+		err = saveWorld(*out, w)
+	} else {
+		err = image.SaveImage(image.CreateImageFromWorld(w), *out)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "Saved %s (%dx%d, seed %d)\n", *out, w.Width, w.Height, w.Seed)
+	return nil
+}
+
+func saveWorld(path string, w *world.World) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := w.Save(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -3,8 +3,7 @@ module tilemap-generator
 go 1.22
 
 require (
-	github.com/aquilax/go-perlin v1.1.0 // indirect
-	github.com/fogleman/gg v1.3.0 // indirect
-	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
-	golang.org/x/image v0.21.0 // indirect
+	github.com/fogleman/gg v1.3.0
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
+	golang.org/x/image v0.21.0
 )
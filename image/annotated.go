@@ -0,0 +1,284 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"sync"
+	"tilemap-generator/mapgen/world"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+type AnnotateOptions struct {
+	// Подложка карты, например отмывка или горизонтали. Default: CreateImageFromWorld.
+	// Растягивается до размера мира, умноженного на Scale.
+	Base image.Image
+	// Пикселей на клетку. Default: 2.
+	Scale int
+	// Подписывать биомы в легенде по Name вместо NameRU.
+	UseName bool
+
+	// Длина клетки в единицах Unit для масштабной линейки. Default: 1 "cells".
+	CellSize float64
+	Unit     string
+
+	// Шаг координатной сетки в клетках; 0 — без сетки.
+	Grid int64
+	// Подпись под картой; сид добавляется всегда.
+	Caption string
+
+	// Кегль шрифта в пунктах. Default: 14.
+	FontSize float64
+}
+
+func (o AnnotateOptions) withDefaults() AnnotateOptions {
+	if o.Scale < 1 {
+		o.Scale = 2
+	}
+	if o.CellSize == 0 {
+		o.CellSize = 1
+	}
+	if o.Unit == "" {
+		o.Unit = "cells"
+	}
+	if o.FontSize == 0 {
+		o.FontSize = 14
+	}
+	return o
+}
+
+var (
+	annotationFont     *truetype.Font
+	annotationFontErr  error
+	annotationFontOnce sync.Once
+)
+
+// fontFace возвращает Go Regular нужного кегля: он встроен в бинарь и содержит кириллицу.
+func fontFace(size float64) (font.Face, error) {
+	annotationFontOnce.Do(func() {
+		annotationFont, annotationFontErr = truetype.Parse(goregular.TTF)
+	})
+	if annotationFontErr != nil {
+		return nil, annotationFontErr
+	}
+	return truetype.NewFace(annotationFont, &truetype.Options{Size: size}), nil
+}
+
+var (
+	inkColor   = color.RGBA{40, 40, 40, 255}
+	paperColor = color.RGBA{250, 248, 242, 255}
+	panelColor = color.NRGBA{255, 255, 255, 210}
+)
+
+// CreateAnnotatedImage рисует карту для презентации: легенда биомов справа,
+// масштабная линейка и стрелка севера поверх карты, сетка координат по полям
+// и подпись с сидом внизу.
+func CreateAnnotatedImage(w *world.World, opts AnnotateOptions) (image.Image, error) {
+	opts = opts.withDefaults()
+
+	face, err := fontFace(opts.FontSize)
+	if err != nil {
+		return nil, fmt.Errorf("image: font: %w", err)
+	}
+
+	base := opts.Base
+	if base == nil {
+		base = CreateImageFromWorld(w)
+	}
+	mapW, mapH := int(w.Width)*opts.Scale, int(w.Height)*opts.Scale
+	mapImg := scaleNearest(base, mapW, mapH)
+
+	// Размеры текста нужны до создания холста, поэтому меряем во временном контексте
+	measure := gg.NewContext(1, 1)
+	measure.SetFontFace(face)
+	lineH := opts.FontSize * 1.6
+	swatch := opts.FontSize
+
+	type entry struct {
+		label string
+		color color.Color
+	}
+	var legend []entry
+	seen := make(map[string]bool)
+	legendW := 0.0
+	for _, b := range w.Palette() {
+		label := b.NameRU
+		if opts.UseName || label == "" {
+			label = b.Name
+		}
+		if seen[label] {
+			continue
+		}
+		seen[label] = true
+
+		c, err := parseHexColor(b.Color)
+		if err != nil {
+			c = color.Gray{128}
+		}
+		legend = append(legend, entry{label, c})
+		tw, _ := measure.MeasureString(label)
+		legendW = math.Max(legendW, swatch+opts.FontSize/2+tw)
+	}
+
+	margin := opts.FontSize * 1.5
+	if opts.Grid > 0 {
+		labelW, _ := measure.MeasureString(strconv.FormatInt(max(w.Width, w.Height), 10))
+		margin = math.Max(margin, labelW+opts.FontSize)
+	}
+	caption := fmt.Sprintf("Seed: %d", w.Seed)
+	if opts.Caption != "" {
+		caption = opts.Caption + " · " + caption
+	}
+
+	legendH := float64(len(legend))*lineH + lineH
+	// Поле слева и сверху шире при сетке (там подписи координат), справа и снизу — обычное
+	pad := opts.FontSize * 1.5
+	width := margin + float64(mapW) + pad + legendW + pad
+	height := margin + math.Max(float64(mapH), legendH) + lineH + pad/2
+
+	dc := gg.NewContext(int(math.Ceil(width)), int(math.Ceil(height)))
+	dc.SetFontFace(face)
+	dc.SetColor(paperColor)
+	dc.Clear()
+
+	mx, my := margin, margin
+	dc.DrawImage(mapImg, int(mx), int(my))
+	dc.SetColor(inkColor)
+	dc.SetLineWidth(1)
+	dc.DrawRectangle(mx+0.5, my+0.5, float64(mapW)-1, float64(mapH)-1)
+	dc.Stroke()
+
+	if opts.Grid > 0 {
+		drawGrid(dc, w, opts, mx, my)
+	}
+	drawScaleBar(dc, w, opts, mx, my+float64(mapH))
+	drawNorthArrow(dc, opts, mx+float64(mapW), my)
+
+	// Легенда
+	lx := mx + float64(mapW) + pad
+	dc.SetColor(inkColor)
+	dc.DrawStringAnchored("Legend", lx, my+lineH/2, 0, 0.5)
+	for i, e := range legend {
+		y := my + float64(i+1)*lineH + lineH/2
+		dc.DrawRectangle(lx, y-swatch/2, swatch, swatch)
+		dc.SetColor(e.color)
+		dc.FillPreserve()
+		dc.SetColor(inkColor)
+		dc.Stroke()
+		dc.DrawStringAnchored(e.label, lx+swatch+opts.FontSize/2, y, 0, 0.35)
+	}
+
+	dc.DrawStringAnchored(caption, mx, height-pad/2-lineH/2, 0, 0.35)
+
+	return dc.Image(), nil
+}
+
+// scaleNearest растягивает изображение без сглаживания, чтобы клетки оставались чёткими.
+func scaleNearest(src image.Image, width, height int) image.Image {
+	b := src.Bounds()
+	if b.Dx() == width && b.Dy() == height {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dst.Set(x, y, src.At(b.Min.X+x*b.Dx()/width, b.Min.Y+y*b.Dy()/height))
+		}
+	}
+	return dst
+}
+
+func drawGrid(dc *gg.Context, w *world.World, opts AnnotateOptions, mx, my float64) {
+	s := float64(opts.Scale)
+	dc.Push()
+	defer dc.Pop()
+
+	dc.SetColor(color.NRGBA{0, 0, 0, 70})
+	dc.SetLineWidth(1)
+	dc.SetDash(4, 4)
+	for x := opts.Grid; x < w.Width; x += opts.Grid {
+		dc.DrawLine(mx+float64(x)*s, my, mx+float64(x)*s, my+float64(w.Height)*s)
+	}
+	for y := opts.Grid; y < w.Height; y += opts.Grid {
+		dc.DrawLine(mx, my+float64(y)*s, mx+float64(w.Width)*s, my+float64(y)*s)
+	}
+	dc.Stroke()
+
+	dc.SetColor(inkColor)
+	for x := int64(0); x < w.Width; x += opts.Grid {
+		dc.DrawStringAnchored(strconv.FormatInt(x, 10), mx+float64(x)*s, my-opts.FontSize/3, 0.5, 0)
+	}
+	for y := int64(0); y < w.Height; y += opts.Grid {
+		dc.DrawStringAnchored(strconv.FormatInt(y, 10), mx-opts.FontSize/3, my+float64(y)*s, 1, 0.35)
+	}
+}
+
+// drawScaleBar рисует линейку круглой длины (1, 2 или 5 × 10^n) примерно на пятую часть карты.
+func drawScaleBar(dc *gg.Context, w *world.World, opts AnnotateOptions, left, bottom float64) {
+	length := niceNumber(float64(w.Width) * opts.CellSize / 5)
+	px := length / opts.CellSize * float64(opts.Scale)
+	label := strconv.FormatFloat(length, 'f', -1, 64) + " " + opts.Unit
+	labelW, _ := dc.MeasureString(label)
+
+	pad := opts.FontSize / 2
+	x, y := left+pad, bottom-pad
+	barH := opts.FontSize / 2
+
+	dc.SetColor(panelColor)
+	dc.DrawRectangle(x-pad/2, y-barH-opts.FontSize*1.5, math.Max(px, labelW)+pad, barH+opts.FontSize*1.5+pad/2)
+	dc.Fill()
+
+	// Две половины разного цвета, как на топографических картах
+	dc.SetColor(inkColor)
+	dc.DrawRectangle(x, y-barH, px/2, barH)
+	dc.Fill()
+	dc.SetLineWidth(1)
+	dc.DrawRectangle(x, y-barH, px, barH)
+	dc.Stroke()
+	dc.DrawStringAnchored(label, x, y-barH-opts.FontSize/3, 0, 0)
+}
+
+func niceNumber(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	exp := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{5, 2, 1} {
+		if m*exp <= v {
+			return m * exp
+		}
+	}
+	return exp
+}
+
+func drawNorthArrow(dc *gg.Context, opts AnnotateOptions, right, top float64) {
+	size := opts.FontSize * 2.5
+	radius := size * 0.75
+	cx, cy := right-radius-opts.FontSize/2, top+radius+opts.FontSize/2
+
+	dc.SetColor(panelColor)
+	dc.DrawCircle(cx, cy, radius)
+	dc.Fill()
+
+	// Левая половина стрелки залита, правая — контур
+	dc.SetColor(inkColor)
+	dc.MoveTo(cx, cy-size*0.3)
+	dc.LineTo(cx-size/4, cy+size/2)
+	dc.LineTo(cx, cy+size/4)
+	dc.ClosePath()
+	dc.Fill()
+	dc.MoveTo(cx, cy-size*0.3)
+	dc.LineTo(cx+size/4, cy+size/2)
+	dc.LineTo(cx, cy+size/4)
+	dc.ClosePath()
+	dc.SetLineWidth(1)
+	dc.Stroke()
+	dc.DrawStringAnchored("N", cx, cy-size*0.35, 0.5, 0)
+}
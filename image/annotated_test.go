@@ -0,0 +1,52 @@
+package image
+
+import (
+	"image/color"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+func TestNiceNumber(t *testing.T) {
+	for v, want := range map[float64]float64{0.7: 0.5, 3: 2, 9.9: 5, 12: 10, 260: 200} {
+		if got := niceNumber(v); got != want {
+			t.Errorf("niceNumber(%v) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestCreateAnnotatedImage(t *testing.T) {
+	sea := biome.Data{Name: "Liquid", NameRU: "Океан", Color: "#4292c4"}
+	field := biome.Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"}
+	matrix := make([][]biome.Data, 40)
+	for y := range matrix {
+		matrix[y] = make([]biome.Data, 60)
+		for x := range matrix[y] {
+			matrix[y][x] = sea
+			if x > 30 {
+				matrix[y][x] = field
+			}
+		}
+	}
+	w := world.NewWorld(matrix, 42)
+
+	img, err := CreateAnnotatedImage(w, AnnotateOptions{Scale: 3, Grid: 20, Caption: "Test"})
+	if err != nil {
+		t.Fatalf("CreateAnnotatedImage: %v", err)
+	}
+
+	b := img.Bounds()
+	if b.Dx() <= 180 || b.Dy() <= 120 {
+		t.Fatalf("image %v is not larger than the map", b)
+	}
+
+	// Середина карты не закрыта аннотациями: в строке должен встретиться цвет лугов
+	found := false
+	for x := 0; x < b.Dx() && !found; x++ {
+		r, g, bl, _ := img.At(x, b.Dy()/2).RGBA()
+		found = color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8), 255} == color.RGBA{0x5d, 0xbc, 0x21, 255}
+	}
+	if !found {
+		t.Errorf("map colors are missing from the annotated image")
+	}
+}
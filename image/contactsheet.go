@@ -0,0 +1,117 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+type ContactSheetOptions struct {
+	// Наибольшая сторона миниатюры в пикселях. Default: 256.
+	ThumbSize int
+	// Число столбцов. Default: квадратная сетка.
+	Columns int
+	// Кегль подписей в пунктах. Default: 12.
+	FontSize float64
+}
+
+func (o ContactSheetOptions) withDefaults(n int) ContactSheetOptions {
+	if o.ThumbSize <= 0 {
+		o.ThumbSize = 256
+	}
+	if o.Columns <= 0 {
+		o.Columns = max(1, int(math.Ceil(math.Sqrt(float64(n)))))
+	}
+	if o.FontSize == 0 {
+		o.FontSize = 12
+	}
+	return o
+}
+
+// ContactSheetItem — миниатюра и строки подписи под ней.
+type ContactSheetItem struct {
+	Image image.Image
+	Lines []string
+}
+
+// Thumbnail вписывает изображение в квадрат size×size с сохранением пропорций.
+func Thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= size && b.Dy() <= size {
+		return img
+	}
+	k := float64(size) / float64(max(b.Dx(), b.Dy()))
+	return scaleNearest(img, max(1, int(float64(b.Dx())*k)), max(1, int(float64(b.Dy())*k)))
+}
+
+// CreateContactSheet раскладывает миниатюры сеткой с подписями, чтобы сравнить
+// много вариантов карты на одном листе.
+func CreateContactSheet(items []ContactSheetItem, opts ContactSheetOptions) (image.Image, error) {
+	if len(items) == 0 {
+		return nil, errors.New("image: contact sheet has no items")
+	}
+	opts = opts.withDefaults(len(items))
+
+	face, err := fontFace(opts.FontSize)
+	if err != nil {
+		return nil, fmt.Errorf("image: font: %w", err)
+	}
+
+	lines := 0
+	for _, item := range items {
+		lines = max(lines, len(item.Lines))
+	}
+	lineH := opts.FontSize * 1.4
+	pad := opts.FontSize
+	thumb := float64(opts.ThumbSize)
+	cellW := thumb + pad
+	cellH := thumb + pad/2 + float64(lines)*lineH + pad
+	cols := min(opts.Columns, len(items))
+	rows := (len(items) + cols - 1) / cols
+
+	dc := gg.NewContext(int(math.Ceil(pad+float64(cols)*cellW)), int(math.Ceil(pad+float64(rows)*cellH)))
+	dc.SetFontFace(face)
+	dc.SetColor(paperColor)
+	dc.Clear()
+
+	for i, item := range items {
+		x := pad + float64(i%cols)*cellW
+		y := pad + float64(i/cols)*cellH
+
+		// Миниатюра по центру квадрата, квадрат обведён рамкой
+		if item.Image != nil {
+			img := Thumbnail(item.Image, opts.ThumbSize)
+			b := img.Bounds()
+			dc.DrawImage(img, int(x)+(opts.ThumbSize-b.Dx())/2, int(y)+(opts.ThumbSize-b.Dy())/2)
+		}
+		dc.SetColor(inkColor)
+		dc.SetLineWidth(1)
+		dc.DrawRectangle(x+0.5, y+0.5, thumb-1, thumb-1)
+		dc.Stroke()
+
+		for j, line := range item.Lines {
+			ly := y + thumb + pad/2 + float64(j)*lineH + lineH/2
+			dc.DrawStringAnchored(fitString(dc, line, thumb), x, ly, 0, 0.35)
+		}
+	}
+
+	return dc.Image(), nil
+}
+
+// fitString обрезает строку с многоточием, чтобы подпись не залезала на соседа.
+func fitString(dc *gg.Context, s string, width float64) string {
+	if w, _ := dc.MeasureString(s); w <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if w, _ := dc.MeasureString(string(runes) + "…"); w <= width {
+			break
+		}
+	}
+	return string(runes) + "…"
+}
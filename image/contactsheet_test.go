@@ -0,0 +1,43 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCreateContactSheet(t *testing.T) {
+	wide := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			wide.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+	items := make([]ContactSheetItem, 5)
+	for i := range items {
+		items[i] = ContactSheetItem{Image: wide, Lines: []string{"Seed 1", "a very long caption that does not fit under a thumbnail"}}
+	}
+
+	img, err := CreateContactSheet(items, ContactSheetOptions{ThumbSize: 50})
+	if err != nil {
+		t.Fatalf("CreateContactSheet: %v", err)
+	}
+
+	// 5 миниатюр — сетка 3×2
+	b := img.Bounds()
+	if b.Dx() < 150 || b.Dx() > 250 || b.Dy() < 100 || b.Dy() > 250 {
+		t.Errorf("sheet size %v", b.Size())
+	}
+	// Широкая картинка вписана по ширине и центрирована по высоте
+	pad := 12
+	if r, _, _, _ := img.At(pad+25, pad+25).RGBA(); r>>8 != 255 {
+		t.Errorf("thumbnail centre is not red")
+	}
+	if r, g, _, _ := img.At(pad+25, pad+5).RGBA(); r>>8 == 255 && g>>8 == 0 {
+		t.Errorf("thumbnail is not centred vertically")
+	}
+
+	if _, err := CreateContactSheet(nil, ContactSheetOptions{}); err == nil {
+		t.Error("expected error for an empty sheet")
+	}
+}
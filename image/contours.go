@@ -0,0 +1,220 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"tilemap-generator/mapgen/world"
+)
+
+type ContourOptions struct {
+	// Шаг между горизонталями в единицах высоты [0, 1], не меньше MinContourInterval.
+	// Default: 0.05.
+	Interval float64
+	// Каждая N-я горизонталь рисуется утолщённой (индексная). Default: 5.
+	IndexEvery int
+	// Цвет линий. Default: "#5a3c1e".
+	Color string
+	// Толщина обычных и индексных линий в пикселях. Default: 1 и 2.
+	Width, IndexWidth float64
+	// Пикселей на клетку для CreateContourImageFromWorld. Default: 1.
+	Scale int
+}
+
+func (o ContourOptions) withDefaults() ContourOptions {
+	if o.Interval == 0 {
+		o.Interval = 0.05
+	}
+	if o.IndexEvery == 0 {
+		o.IndexEvery = 5
+	}
+	if o.Color == "" {
+		o.Color = "#5a3c1e"
+	}
+	if o.Width == 0 {
+		o.Width = 1
+	}
+	if o.IndexWidth == 0 {
+		o.IndexWidth = 2
+	}
+	if o.Scale < 1 {
+		o.Scale = 1
+	}
+	return o
+}
+
+// MinContourInterval ограничивает число горизонталей тысячей уровней.
+const MinContourInterval = 0.001
+
+func (o ContourOptions) validate() error {
+	if o.Interval < MinContourInterval || o.Interval >= 1 || math.IsNaN(o.Interval) {
+		return fmt.Errorf("image: contour interval must be within [%g, 1), got %g", MinContourInterval, o.Interval)
+	}
+	if o.IndexEvery < 1 {
+		return fmt.Errorf("image: index contour step must be positive, got %d", o.IndexEvery)
+	}
+	return nil
+}
+
+// Segment — отрезок изолинии в координатах клеток (центр клетки (x, y) — точка (x+0.5, y+0.5)).
+type Segment struct {
+	X0, Y0, X1, Y1 float64
+}
+
+// CreateContourImageFromWorld рисует карту биомов и поверх неё горизонтали.
+func CreateContourImageFromWorld(w *world.World, opts ContourOptions) (image.Image, error) {
+	opts = opts.withDefaults()
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	base := CreateImageFromWorld(w)
+	img := image.NewRGBA(image.Rect(0, 0, int(w.Width)*opts.Scale, int(w.Height)*opts.Scale))
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			img.Set(x, y, base.At(x/opts.Scale, y/opts.Scale))
+		}
+	}
+
+	if err := DrawContours(img, w, opts); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// DrawContours рисует горизонтали поверх готового изображения мира.
+// Масштаб берётся из соотношения размеров картинки и мира.
+func DrawContours(img draw.Image, w *world.World, opts ContourOptions) error {
+	if !w.HasHeights() {
+		return ErrNoHeights
+	}
+	opts = opts.withDefaults()
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	c, err := parseHexColor(opts.Color)
+	if err != nil {
+		return fmt.Errorf("image: contour color: %w", err)
+	}
+	scaleX := float64(img.Bounds().Dx()) / float64(w.Width)
+	scaleY := float64(img.Bounds().Dy()) / float64(w.Height)
+
+	for i := 1; float64(i)*opts.Interval < 1; i++ {
+		width := opts.Width
+		if i%opts.IndexEvery == 0 {
+			width = opts.IndexWidth
+		}
+		for _, s := range ContourSegments(w.Heights, float64(i)*opts.Interval) {
+			drawSegment(img, s.X0*scaleX, s.Y0*scaleY, s.X1*scaleX, s.Y1*scaleY, width, c)
+		}
+	}
+
+	return nil
+}
+
+// ContourSegments строит изолинию уровня level методом marching squares
+// по сетке центров клеток.
+func ContourSegments(heights [][]float64, level float64) []Segment {
+	var segments []Segment
+
+	for y := 0; y+1 < len(heights); y++ {
+		for x := 0; x+1 < len(heights[y]); x++ {
+			// Углы квадрата по часовой: верхний левый, верхний правый, нижний правый, нижний левый
+			v := [4]float64{heights[y][x], heights[y][x+1], heights[y+1][x+1], heights[y+1][x]}
+			cx := [4]float64{0, 1, 1, 0}
+			cy := [4]float64{0, 0, 1, 1}
+
+			idx := 0
+			for k := 0; k < 4; k++ {
+				if v[k] >= level {
+					idx |= 1 << k
+				}
+			}
+			if idx == 0 || idx == 15 {
+				continue
+			}
+
+			// Точка пересечения уровня с ребром между углами a и b
+			edge := func(a, b int) (float64, float64) {
+				t := 0.5
+				if v[b] != v[a] {
+					t = (level - v[a]) / (v[b] - v[a])
+				}
+				return float64(x) + 0.5 + cx[a] + (cx[b]-cx[a])*t, float64(y) + 0.5 + cy[a] + (cy[b]-cy[a])*t
+			}
+			add := func(a1, b1, a2, b2 int) {
+				x0, y0 := edge(a1, b1)
+				x1, y1 := edge(a2, b2)
+				segments = append(segments, Segment{x0, y0, x1, y1})
+			}
+
+			// Рёбра: 0 — верх (0-1), 1 — право (1-2), 2 — низ (2-3), 3 — лево (3-0)
+			switch idx {
+			case 1, 14:
+				add(3, 0, 0, 1)
+			case 2, 13:
+				add(0, 1, 1, 2)
+			case 3, 12:
+				add(3, 0, 1, 2)
+			case 4, 11:
+				add(1, 2, 2, 3)
+			case 6, 9:
+				add(0, 1, 2, 3)
+			case 7, 8:
+				add(2, 3, 3, 0)
+			case 5, 10:
+				// Седловая точка: решаем по среднему значению в центре квадрата
+				center := (v[0] + v[1] + v[2] + v[3]) / 4
+				if (center >= level) == (idx == 5) {
+					add(3, 0, 2, 3)
+					add(0, 1, 1, 2)
+				} else {
+					add(3, 0, 0, 1)
+					add(1, 2, 2, 3)
+				}
+			}
+		}
+	}
+
+	return segments
+}
+
+// drawSegment рисует сглаженный отрезок заданной толщины: покрытие пикселя
+// считается по расстоянию от его центра до отрезка.
+func drawSegment(img draw.Image, x0, y0, x1, y1, width float64, c color.Color) {
+	half := width / 2
+	bounds := img.Bounds()
+	minX := max(int(math.Floor(math.Min(x0, x1)-half-1)), bounds.Min.X)
+	maxX := min(int(math.Ceil(math.Max(x0, x1)+half+1)), bounds.Max.X-1)
+	minY := max(int(math.Floor(math.Min(y0, y1)-half-1)), bounds.Min.Y)
+	maxY := min(int(math.Ceil(math.Max(y0, y1)+half+1)), bounds.Max.Y-1)
+
+	cr, cg, cb, _ := c.RGBA()
+	dx, dy := x1-x0, y1-y0
+	lenSq := dx*dx + dy*dy
+
+	for py := minY; py <= maxY; py++ {
+		for px := minX; px <= maxX; px++ {
+			fx, fy := float64(px)+0.5, float64(py)+0.5
+			t := 0.0
+			if lenSq > 0 {
+				t = math.Max(0, math.Min(1, ((fx-x0)*dx+(fy-y0)*dy)/lenSq))
+			}
+			dist := math.Hypot(fx-(x0+t*dx), fy-(y0+t*dy))
+
+			coverage := math.Max(0, math.Min(1, half+0.5-dist))
+			if coverage == 0 {
+				continue
+			}
+
+			br, bg, bb, ba := img.At(px, py).RGBA()
+			mix := func(fg, bg uint32) uint16 {
+				return uint16(float64(bg) + (float64(fg)-float64(bg))*coverage)
+			}
+			img.Set(px, py, color.RGBA64{R: mix(cr, br), G: mix(cg, bg), B: mix(cb, bb), A: uint16(ba)})
+		}
+	}
+}
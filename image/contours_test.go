@@ -0,0 +1,50 @@
+package image
+
+import (
+	"math"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+func TestContourSegmentsFollowLevel(t *testing.T) {
+	// Конус: изолиния 0.5 — окружность радиуса 2 вокруг центра (2.5, 2.5)
+	heights := make([][]float64, 6)
+	for y := range heights {
+		heights[y] = make([]float64, 6)
+		for x := range heights[y] {
+			heights[y][x] = 1 - math.Hypot(float64(x)-2, float64(y)-2)/4
+		}
+	}
+
+	segments := ContourSegments(heights, 0.5)
+	if len(segments) == 0 {
+		t.Fatal("no segments")
+	}
+	for _, s := range segments {
+		for _, p := range [][2]float64{{s.X0, s.Y0}, {s.X1, s.Y1}} {
+			if r := math.Hypot(p[0]-2.5, p[1]-2.5); math.Abs(r-2) > 0.2 {
+				t.Errorf("point %v is %.3f from the center, want ~2", p, r)
+			}
+		}
+	}
+}
+
+func TestContourOptionsRejectInvalidSteps(t *testing.T) {
+	heights := [][]float64{{0, 1}, {1, 0}}
+	w := world.NewWorldWithHeights([][]biome.Data{{{}, {}}, {{}, {}}}, heights, 1)
+
+	for _, opts := range []ContourOptions{
+		{Interval: -0.1},
+		{Interval: 1e-9},
+		{Interval: math.NaN()},
+		{IndexEvery: -2},
+	} {
+		if _, err := CreateContourImageFromWorld(w, opts); err == nil {
+			t.Errorf("%+v: expected error", opts)
+		}
+	}
+	if _, err := CreateContourImageFromWorld(w, ContourOptions{}); err != nil {
+		t.Errorf("defaults: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package image
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"tilemap-generator/mapgen/world"
+)
+
+var ErrNoHeights = errors.New("image: world has no height layer")
+
+type HeightmapOptions struct {
+	// Диапазон высот, который растягивается на весь диапазон формата.
+	// Если Min == Max, берётся [0, 1]; при AutoRange — фактический минимум и максимум мира.
+	Min, Max  float64
+	AutoRange bool
+
+	// Если ClampSea включён, всё ниже SeaLevel прижимается к нему, и вода становится плоской.
+	SeaLevel float64
+	ClampSea bool
+}
+
+// normalizer возвращает функцию, переводящую высоту мира в [0, 1] по настройкам.
+func (o HeightmapOptions) normalizer(w *world.World) func(h float64) float64 {
+	lo, hi := o.Min, o.Max
+	if o.AutoRange {
+		lo, hi = math.Inf(1), math.Inf(-1)
+		for _, row := range w.Heights {
+			for _, h := range row {
+				lo, hi = math.Min(lo, h), math.Max(hi, h)
+			}
+		}
+	} else if lo == hi {
+		lo, hi = 0, 1
+	}
+	if o.ClampSea {
+		lo = math.Max(lo, o.SeaLevel)
+	}
+
+	span := hi - lo
+	return func(h float64) float64 {
+		if o.ClampSea && h < o.SeaLevel {
+			h = o.SeaLevel
+		}
+		if span <= 0 {
+			return 0
+		}
+		return math.Max(0, math.Min(1, (h-lo)/span))
+	}
+}
+
+func CreateHeightImage(w *world.World, opts HeightmapOptions) (*image.Gray16, error) {
+	if !w.HasHeights() {
+		return nil, ErrNoHeights
+	}
+
+	norm := opts.normalizer(w)
+	img := image.NewGray16(image.Rect(0, 0, int(w.Width), int(w.Height)))
+	for y := int64(0); y < w.Height; y++ {
+		for x := int64(0); x < w.Width; x++ {
+			v := norm(w.Heights[y][x])
+			img.SetGray16(int(x), int(y), color.Gray16{Y: uint16(math.Round(v * math.MaxUint16))})
+		}
+	}
+
+	return img, nil
+}
+
+// WriteR32 пишет высоты построчно как little-endian float32 без заголовка.
+func WriteR32(out io.Writer, w *world.World, opts HeightmapOptions) error {
+	return writeRaw(out, w, opts, func(buf []byte, v float64) []byte {
+		return binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(v)))
+	})
+}
+
+// WriteR16 пишет высоты построчно как little-endian uint16 без заголовка.
+func WriteR16(out io.Writer, w *world.World, opts HeightmapOptions) error {
+	return writeRaw(out, w, opts, func(buf []byte, v float64) []byte {
+		return binary.LittleEndian.AppendUint16(buf, uint16(math.Round(v*math.MaxUint16)))
+	})
+}
+
+func writeRaw(out io.Writer, w *world.World, opts HeightmapOptions, encode func([]byte, float64) []byte) error {
+	if !w.HasHeights() {
+		return ErrNoHeights
+	}
+
+	norm := opts.normalizer(w)
+	bw := bufio.NewWriter(out)
+	buf := make([]byte, 0, 4*w.Width)
+	for _, row := range w.Heights {
+		buf = buf[:0]
+		for _, h := range row {
+			buf = encode(buf, norm(h))
+		}
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+func SaveR32(w *world.World, opts HeightmapOptions, filename string) error {
+	return saveRaw(filename, func(out io.Writer) error { return WriteR32(out, w, opts) })
+}
+
+func SaveR16(w *world.World, opts HeightmapOptions, filename string) error {
+	return saveRaw(filename, func(out io.Writer) error { return WriteR16(out, w, opts) })
+}
+
+func saveRaw(filename string, write func(io.Writer) error) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return write(file)
+}
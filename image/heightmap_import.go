@@ -0,0 +1,105 @@
+package image
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadHeightmap читает поле высот в [0, 1] из PNG (8 или 16 бит) или из
+// сырого .r32/.r16. Сырые файлы не хранят размеры, поэтому считаются квадратными;
+// для прямоугольных используйте ReadR32/ReadR16 с явными размерами.
+func LoadHeightmap(filename string) ([][]float64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == ".png" {
+		return ReadHeightmapPNG(file)
+	}
+
+	var bytesPerCell int64
+	switch ext {
+	case ".r32":
+		bytesPerCell = 4
+	case ".r16":
+		bytesPerCell = 2
+	default:
+		return nil, fmt.Errorf("image: unsupported heightmap format %q", ext)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	cells := info.Size() / bytesPerCell
+	side := int(math.Sqrt(float64(cells)))
+	if int64(side*side)*bytesPerCell != info.Size() {
+		return nil, fmt.Errorf("image: %s is not a square %s heightmap (%d bytes)", filename, ext, info.Size())
+	}
+
+	if ext == ".r32" {
+		return ReadR32(file, side, side)
+	}
+	return ReadR16(file, side, side)
+}
+
+func ReadHeightmapPNG(r io.Reader) ([][]float64, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	heights := make([][]float64, bounds.Dy())
+	for y := range heights {
+		heights[y] = make([]float64, bounds.Dx())
+		for x := range heights[y] {
+			// Gray16Model сохраняет все 16 бит и для 8-битных картинок даёт то же значение ×257
+			g := color.Gray16Model.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray16)
+			heights[y][x] = float64(g.Y) / math.MaxUint16
+		}
+	}
+
+	return heights, nil
+}
+
+func ReadR32(r io.Reader, width, height int) ([][]float64, error) {
+	return readRaw(r, width, height, 4, func(b []byte) float64 {
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	})
+}
+
+func ReadR16(r io.Reader, width, height int) ([][]float64, error) {
+	return readRaw(r, width, height, 2, func(b []byte) float64 {
+		return float64(binary.LittleEndian.Uint16(b)) / math.MaxUint16
+	})
+}
+
+func readRaw(r io.Reader, width, height, size int, decode func([]byte) float64) ([][]float64, error) {
+	br := bufio.NewReader(r)
+	row := make([]byte, width*size)
+
+	heights := make([][]float64, height)
+	for y := range heights {
+		if _, err := io.ReadFull(br, row); err != nil {
+			return nil, fmt.Errorf("image: heightmap row %d: %w", y, err)
+		}
+		heights[y] = make([]float64, width)
+		for x := range heights[y] {
+			heights[y][x] = decode(row[x*size:])
+		}
+	}
+
+	return heights, nil
+}
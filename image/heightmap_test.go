@@ -0,0 +1,68 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"math"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+func heightWorld() *world.World {
+	matrix := [][]biome.Data{make([]biome.Data, 4)}
+	return world.NewWorldWithHeights(matrix, [][]float64{{0.1, 0.25, 0.5, 1}}, 1)
+}
+
+func TestCreateHeightImageSeaClamp(t *testing.T) {
+	img, err := CreateHeightImage(heightWorld(), HeightmapOptions{SeaLevel: 0.25, ClampSea: true})
+	if err != nil {
+		t.Fatalf("CreateHeightImage: %v", err)
+	}
+
+	want := []uint16{0, 0, 21845, 65535}
+	for x, w := range want {
+		if got := img.Gray16At(x, 0).Y; got != w {
+			t.Errorf("pixel %d = %d, want %d", x, got, w)
+		}
+	}
+}
+
+func TestWriteR32AutoRange(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteR32(&buf, heightWorld(), HeightmapOptions{AutoRange: true}); err != nil {
+		t.Fatalf("WriteR32: %v", err)
+	}
+	if buf.Len() != 16 {
+		t.Fatalf("got %d bytes, want 16", buf.Len())
+	}
+
+	first := math.Float32frombits(binary.LittleEndian.Uint32(buf.Bytes()[0:]))
+	last := math.Float32frombits(binary.LittleEndian.Uint32(buf.Bytes()[12:]))
+	if first != 0 || last != 1 {
+		t.Errorf("auto range gives %v..%v, want 0..1", first, last)
+	}
+}
+
+func TestHeightmapPNGRoundTrip(t *testing.T) {
+	img, err := CreateHeightImage(heightWorld(), HeightmapOptions{})
+	if err != nil {
+		t.Fatalf("CreateHeightImage: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	heights, err := ReadHeightmapPNG(&buf)
+	if err != nil {
+		t.Fatalf("ReadHeightmapPNG: %v", err)
+	}
+
+	for x, want := range heightWorld().Heights[0] {
+		if got := heights[0][x]; math.Abs(got-want) > 1e-4 {
+			t.Errorf("height %d = %v, want %v", x, got, want)
+		}
+	}
+}
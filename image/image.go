@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
 	"image/png"
 	"os"
+	"tilemap-generator/mapgen/biome"
 	"tilemap-generator/mapgen/world"
 )
 
@@ -47,3 +49,20 @@ func SaveImage(img image.Image, filename string) error {
 
 	return png.Encode(file, img)
 }
+
+// CreatePaletteTileset рисует тайлсет из однотонных тайлов цвета биомов,
+// по одному тайлу на биом в одну строку. Порядок совпадает с export.PaletteMapping.
+func CreatePaletteTileset(palette []biome.Data, tileWidth, tileHeight int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, tileWidth*len(palette), tileHeight))
+
+	for i, b := range palette {
+		c, err := parseHexColor(b.Color)
+		if err != nil {
+			fmt.Println("Error parsing hex color:", err)
+			continue
+		}
+		draw.Draw(img, image.Rect(i*tileWidth, 0, (i+1)*tileWidth, tileHeight), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+
+	return img
+}
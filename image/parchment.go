@@ -0,0 +1,356 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/utils"
+	"tilemap-generator/mapgen/world"
+
+	"github.com/fogleman/gg"
+)
+
+// ParchmentPalette — цвета стилизованной карты в формате "#rrggbb".
+// Water, Land, Mountain и Forest — тонировка поверх бумаги, а не заливка.
+type ParchmentPalette struct {
+	Paper      string `json:"paper"`
+	PaperShade string `json:"paper_shade"`
+	Ink        string `json:"ink"`
+	Water      string `json:"water"`
+	Land       string `json:"land"`
+	Mountain   string `json:"mountain"`
+	Forest     string `json:"forest"`
+}
+
+var (
+	SepiaPalette = ParchmentPalette{
+		Paper: "#f1e4c3", PaperShade: "#c9a66b", Ink: "#3b2a1a",
+		Water: "#a9b7a0", Land: "#e8d4a2", Mountain: "#b08d5a", Forest: "#8c9a5b",
+	}
+	AtlasPalette = ParchmentPalette{
+		Paper: "#f4ecd8", PaperShade: "#d2bf94", Ink: "#2b2f3a",
+		Water: "#8fb8c9", Land: "#e9dfb5", Mountain: "#b59b78", Forest: "#7fa36b",
+	}
+	NightPalette = ParchmentPalette{
+		Paper: "#2e3440", PaperShade: "#1b1f27", Ink: "#e5d9b6",
+		Water: "#27405a", Land: "#3d4451", Mountain: "#555b66", Forest: "#34503f",
+	}
+
+	// ParchmentPalettes — встроенные палитры по имени, для настроек и CLI.
+	ParchmentPalettes = map[string]ParchmentPalette{
+		"sepia": SepiaPalette,
+		"atlas": AtlasPalette,
+		"night": NightPalette,
+	}
+)
+
+type ParchmentOptions struct {
+	// Пикселей на клетку. Default: 8.
+	Scale int
+	// Default: SepiaPalette.
+	Palette ParchmentPalette
+	// Сид текстуры бумаги и разброса значков. Default: сид мира.
+	Seed int
+	// Среднее расстояние между значками в клетках. Default: 3.
+	GlyphSpacing float64
+	// Ширина полосы штриховки вдоль берега в клетках. Default: 2.
+	HatchWidth float64
+}
+
+func (o ParchmentOptions) withDefaults(w *world.World) ParchmentOptions {
+	if o.Scale < 1 {
+		o.Scale = 8
+	}
+	if o.Palette == (ParchmentPalette{}) {
+		o.Palette = SepiaPalette
+	}
+	if o.Seed == 0 {
+		o.Seed = w.Seed
+	}
+	if o.GlyphSpacing == 0 {
+		o.GlyphSpacing = 3
+	}
+	if o.HatchWidth == 0 {
+		o.HatchWidth = 2
+	}
+	return o
+}
+
+type terrain int
+
+const (
+	terrainLand terrain = iota
+	terrainWater
+	terrainMountain
+	terrainForest
+)
+
+// terrainOf относит биом к одному из видов значков по группе Name.
+func terrainOf(b biome.Data) terrain {
+	switch b.Name {
+	case biome.WaterGroup:
+		return terrainWater
+	case "Mounts":
+		return terrainMountain
+	case "Fields":
+		return terrainForest
+	}
+	return terrainLand
+}
+
+type parchmentColors struct {
+	paper, shade, ink, water, land, mountain, forest color.RGBA
+}
+
+func (p ParchmentPalette) colors() (parchmentColors, error) {
+	var c parchmentColors
+	for _, f := range []struct {
+		hex string
+		dst *color.RGBA
+	}{
+		{p.Paper, &c.paper}, {p.PaperShade, &c.shade}, {p.Ink, &c.ink},
+		{p.Water, &c.water}, {p.Land, &c.land}, {p.Mountain, &c.mountain}, {p.Forest, &c.forest},
+	} {
+		parsed, err := parseHexColor(f.hex)
+		if err != nil {
+			return c, fmt.Errorf("image: parchment palette color %q: %w", f.hex, err)
+		}
+		*f.dst = parsed.(color.RGBA)
+	}
+	return c, nil
+}
+
+// CreateParchmentImageFromWorld рисует карту в стиле старинного атласа: бумага
+// с шумовой текстурой, штриховка вдоль берегов, значки гор на Mounts, деревьев
+// на Fields и волн в открытом море.
+func CreateParchmentImageFromWorld(w *world.World, opts ParchmentOptions) (image.Image, error) {
+	opts = opts.withDefaults(w)
+	pal, err := opts.Palette.colors()
+	if err != nil {
+		return nil, err
+	}
+
+	scale := float64(opts.Scale)
+	width, height := int(w.Width)*opts.Scale, int(w.Height)*opts.Scale
+	field := coastField(w, int(math.Ceil(opts.HatchWidth))+2)
+
+	paper := utils.New[float64]()
+	paper.Seed = opts.Seed
+	paper.Frequency = 0.004
+	paper.Octaves = 5
+	paper.FractalType(utils.FractalFBm)
+	grain := utils.New[float64]()
+	grain.Seed = opts.Seed + 1
+	grain.Frequency = 0.35
+
+	hatchStep := math.Max(3, scale/2)
+	coastHalf := math.Max(0.75, scale/10)
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for py := 0; py < height; py++ {
+		for px := 0; px < width; px++ {
+			fx, fy := float64(px), float64(py)
+
+			// Бумага: крупные пятна, мелкое зерно и затемнение к краям
+			t := (paper.GetNoise2D(fx, fy)+1)/2*0.7 + grain.GetNoise2D(fx, fy)*0.08
+			nx, ny := fx/float64(width)*2-1, fy/float64(height)*2-1
+			t += 0.35 * (nx*nx + ny*ny) / 2
+			c := lerpColor(pal.paper, pal.shade, t)
+
+			// Поле расстояний интерполируется, поэтому берег получается плавным, а не ступенчатым
+			cx, cy := (fx+0.5)/scale-0.5, (fy+0.5)/scale-0.5
+			f := sampleField(field, cx, cy)
+			cell := w.Matrix[min(int64(fy/scale), w.Height-1)][min(int64(fx/scale), w.Width-1)]
+
+			if f > 0 {
+				c = lerpColor(c, pal.water, 0.45)
+				if f < opts.HatchWidth {
+					// Диагональная штриховка, редеющая от берега
+					d := math.Mod(fx+fy, hatchStep)
+					d = math.Min(d, hatchStep-d)
+					fade := 1 - f/opts.HatchWidth
+					c = lerpColor(c, pal.ink, coverage(d, 0.5)*fade*0.8)
+				}
+			} else {
+				switch terrainOf(cell) {
+				case terrainMountain:
+					c = lerpColor(c, pal.mountain, 0.35)
+				case terrainForest:
+					c = lerpColor(c, pal.forest, 0.3)
+				default:
+					c = lerpColor(c, pal.land, 0.25)
+				}
+			}
+
+			// Линия берега
+			c = lerpColor(c, pal.ink, coverage(math.Abs(f)*scale, coastHalf))
+			img.SetRGBA(px, py, c)
+		}
+	}
+
+	dc := gg.NewContextForImage(img)
+	drawGlyphs(dc, w, field, opts, pal)
+
+	// Двойная рамка
+	dc.SetColor(pal.ink)
+	dc.SetLineWidth(math.Max(1, scale/4))
+	inset := math.Max(3, scale/2)
+	dc.DrawRectangle(inset, inset, float64(width)-2*inset, float64(height)-2*inset)
+	dc.Stroke()
+	dc.SetLineWidth(1)
+	inset *= 2
+	dc.DrawRectangle(inset, inset, float64(width)-2*inset, float64(height)-2*inset)
+	dc.Stroke()
+
+	return dc.Image(), nil
+}
+
+// coastField — знаковое расстояние от центра клетки до берега в клетках:
+// положительное в воде, отрицательное на суше. Дальше radius клеток значение обрезается.
+func coastField(w *world.World, radius int) [][]float64 {
+	field := w.DistanceToCoast()
+	for _, row := range field {
+		for x, d := range row {
+			// Берег проходит посередине между центрами соседних клеток
+			v := math.Min(math.Abs(d), float64(radius)) - 0.5
+			if d > 0 {
+				v = -v
+			}
+			row[x] = v
+		}
+	}
+	return field
+}
+
+func sampleField(field [][]float64, x, y float64) float64 {
+	h, w := len(field), len(field[0])
+	x = math.Max(0, math.Min(float64(w-1), x))
+	y = math.Max(0, math.Min(float64(h-1), y))
+	x0, y0 := int(x), int(y)
+	x1, y1 := min(x0+1, w-1), min(y0+1, h-1)
+	tx, ty := x-float64(x0), y-float64(y0)
+
+	top := field[y0][x0] + (field[y0][x1]-field[y0][x0])*tx
+	bottom := field[y1][x0] + (field[y1][x1]-field[y1][x0])*tx
+	return top + (bottom-top)*ty
+}
+
+// coverage — доля пикселя, покрытая линией полутолщины half на расстоянии d.
+func coverage(d, half float64) float64 {
+	return math.Max(0, math.Min(1, half+0.5-d))
+}
+
+func lerpColor(a, b color.RGBA, t float64) color.RGBA {
+	t = math.Max(0, math.Min(1, t))
+	mix := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5) }
+	return color.RGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: 255}
+}
+
+type glyph struct {
+	kind terrain
+	x, y float64 // основание значка в пикселях
+	size float64
+}
+
+// drawGlyphs расставляет значки по сетке со случайным сдвигом и рисует сверху вниз,
+// чтобы нижние значки перекрывали верхние, как на рисованных картах.
+func drawGlyphs(dc *gg.Context, w *world.World, field [][]float64, opts ParchmentOptions, pal parchmentColors) {
+	rng := rand.New(rand.NewSource(int64(opts.Seed)))
+	scale := float64(opts.Scale)
+	step := opts.GlyphSpacing
+
+	var glyphs []glyph
+	for gy := 0.0; gy < float64(w.Height); gy += step {
+		for gx := 0.0; gx < float64(w.Width); gx += step {
+			x := gx + rng.Float64()*step
+			y := gy + rng.Float64()*step
+			roll := rng.Float64()
+			if x >= float64(w.Width) || y >= float64(w.Height) {
+				continue
+			}
+
+			kind := terrainOf(w.Matrix[int64(y)][int64(x)])
+			size := step * scale * (0.8 + 0.3*roll)
+			switch kind {
+			case terrainMountain, terrainForest:
+				if field[int64(y)][int64(x)] > -1 {
+					continue // не рисуем у самой воды
+				}
+			case terrainWater:
+				// Волны только в открытом море и не в каждой ячейке сетки
+				if field[int64(y)][int64(x)] < opts.HatchWidth+1 || roll > 0.5 {
+					continue
+				}
+			default:
+				continue
+			}
+			glyphs = append(glyphs, glyph{kind, x * scale, y * scale, size})
+		}
+	}
+	sort.SliceStable(glyphs, func(i, j int) bool { return glyphs[i].y < glyphs[j].y })
+
+	dc.SetLineCapRound()
+	dc.SetLineJoinRound()
+	dc.SetLineWidth(math.Max(1, scale/7))
+	for _, g := range glyphs {
+		switch g.kind {
+		case terrainMountain:
+			drawMountain(dc, g, pal)
+		case terrainForest:
+			drawTree(dc, g, pal)
+		case terrainWater:
+			drawWave(dc, g, pal)
+		}
+	}
+}
+
+func drawMountain(dc *gg.Context, g glyph, pal parchmentColors) {
+	half, peak := g.size/2, g.size*0.75
+
+	// Силуэт заливается бумагой, чтобы перекрыть значки позади
+	dc.MoveTo(g.x-half, g.y)
+	dc.LineTo(g.x, g.y-peak)
+	dc.LineTo(g.x+half, g.y)
+	dc.ClosePath()
+	dc.SetColor(lerpColor(pal.paper, pal.mountain, 0.35))
+	dc.Fill()
+
+	// Теневой склон справа штрихуется линиями, параллельными левому склону
+	dc.SetColor(pal.ink)
+	for i := 1; i <= 3; i++ {
+		t := float64(i) / 4
+		dc.DrawLine(g.x+half*t*0.5, g.y-peak*(1-t*0.5), g.x+half*t, g.y)
+	}
+	dc.MoveTo(g.x-half, g.y)
+	dc.LineTo(g.x, g.y-peak)
+	dc.LineTo(g.x+half, g.y)
+	dc.Stroke()
+}
+
+func drawTree(dc *gg.Context, g glyph, pal parchmentColors) {
+	r := g.size * 0.22
+	dc.SetColor(pal.ink)
+	dc.DrawLine(g.x, g.y, g.x, g.y-g.size*0.35)
+	dc.Stroke()
+
+	dc.DrawCircle(g.x, g.y-g.size*0.35-r, r)
+	dc.SetColor(lerpColor(pal.paper, pal.forest, 0.7))
+	dc.FillPreserve()
+	dc.SetColor(pal.ink)
+	dc.Stroke()
+}
+
+func drawWave(dc *gg.Context, g glyph, pal parchmentColors) {
+	r := g.size / 8
+	dc.SetColor(pal.ink)
+	dc.NewSubPath()
+	dc.DrawArc(g.x-r, g.y, r, math.Pi, 2*math.Pi)
+	dc.Stroke()
+	dc.NewSubPath()
+	dc.DrawArc(g.x+r, g.y, r, math.Pi, 2*math.Pi)
+	dc.Stroke()
+}
@@ -0,0 +1,61 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+func islandWorld() *world.World {
+	sea := biome.Data{Name: "Liquid", NameRU: "Океан", Color: "#4292c4"}
+	hill := biome.Data{Name: "Mounts", NameRU: "Горы", Color: "#8a8a8a"}
+	matrix := make([][]biome.Data, 12)
+	for y := range matrix {
+		matrix[y] = make([]biome.Data, 12)
+		for x := range matrix[y] {
+			matrix[y][x] = sea
+			if x >= 4 && x < 8 && y >= 4 && y < 8 {
+				matrix[y][x] = hill
+			}
+		}
+	}
+	return world.NewWorld(matrix, 3)
+}
+
+func TestCoastFieldSign(t *testing.T) {
+	field := coastField(islandWorld(), 3)
+
+	if f := field[4][4]; f != -0.5 {
+		t.Errorf("land at the coast = %v, want -0.5", f)
+	}
+	if f := field[5][3]; f != 0.5 {
+		t.Errorf("water at the coast = %v, want 0.5", f)
+	}
+	if f := field[0][0]; f <= 2 {
+		t.Errorf("open sea = %v, want > 2", f)
+	}
+}
+
+func TestParchmentDeterministic(t *testing.T) {
+	render := func(opts ParchmentOptions) []byte {
+		img, err := CreateParchmentImageFromWorld(islandWorld(), opts)
+		if err != nil {
+			t.Fatalf("CreateParchmentImageFromWorld: %v", err)
+		}
+		return img.(*image.RGBA).Pix
+	}
+
+	a, b := render(ParchmentOptions{Scale: 4}), render(ParchmentOptions{Scale: 4})
+	if !bytes.Equal(a, b) {
+		t.Errorf("same seed produced different images")
+	}
+	if night := render(ParchmentOptions{Scale: 4, Palette: NightPalette}); bytes.Equal(a, night) {
+		t.Errorf("palette has no effect")
+	}
+
+	if _, err := CreateParchmentImageFromWorld(islandWorld(), ParchmentOptions{Palette: ParchmentPalette{Paper: "bad"}}); err == nil {
+		t.Errorf("expected an error for an invalid palette color")
+	}
+}
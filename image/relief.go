@@ -0,0 +1,153 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"tilemap-generator/mapgen/world"
+)
+
+// ShadeOptions — параметры отмывки. Нули здесь — настоящие значения (солнце
+// с севера, чёрные тени), поэтому начинать стоит с NewShadeOptions.
+type ShadeOptions struct {
+	// Направление на солнце в градусах по часовой стрелке от севера (верх карты).
+	Azimuth float64
+	// Высота солнца над горизонтом в градусах, (0, 90].
+	Altitude float64
+	// Во сколько раз высота [0, 1] больше размера клетки.
+	ZFactor float64
+	// Минимальная освещённость на теневых склонах, 0..1.
+	Ambient float64
+
+	// Затенение впадин по горизонту вокруг клетки.
+	AmbientOcclusion bool
+	// Радиус поиска горизонта в клетках.
+	AORadius int
+	// Сила затенения, 0..1.
+	AOStrength float64
+}
+
+// NewShadeOptions возвращает классическую картографическую подсветку: солнце
+// на северо-западе (315°) на высоте 45°, ZFactor 150, Ambient 0.35,
+// затенение впадин выключено (радиус 8, сила 0.5).
+func NewShadeOptions() ShadeOptions {
+	return ShadeOptions{
+		Azimuth:    315,
+		Altitude:   45,
+		ZFactor:    150,
+		Ambient:    0.35,
+		AORadius:   8,
+		AOStrength: 0.5,
+	}
+}
+
+func (o ShadeOptions) validate() error {
+	switch {
+	case !(o.Altitude > 0 && o.Altitude <= 90):
+		return fmt.Errorf("image: sun altitude must be within (0, 90], got %g", o.Altitude)
+	case !(o.ZFactor >= 0):
+		return fmt.Errorf("image: z factor must not be negative, got %g", o.ZFactor)
+	case !(o.Ambient >= 0 && o.Ambient <= 1):
+		return fmt.Errorf("image: ambient light must be within [0, 1], got %g", o.Ambient)
+	case o.AmbientOcclusion && o.AORadius < 1:
+		return fmt.Errorf("image: occlusion radius must be positive, got %d", o.AORadius)
+	case o.AmbientOcclusion && !(o.AOStrength >= 0 && o.AOStrength <= 1):
+		return fmt.Errorf("image: occlusion strength must be within [0, 1], got %g", o.AOStrength)
+	}
+	return nil
+}
+
+// CreateShadedImageFromWorld рисует цвета биомов, умноженные на отмывку рельефа.
+// Ровная поверхность сохраняет исходный цвет, склоны к солнцу светлеют, от солнца — темнеют.
+func CreateShadedImageFromWorld(w *world.World, opts ShadeOptions) (image.Image, error) {
+	shade, err := Hillshade(w, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, int(w.Width), int(w.Height)))
+	for y := int64(0); y < w.Height; y++ {
+		for x := int64(0); x < w.Width; x++ {
+			c, err := parseHexColor(w.Matrix[y][x].Color)
+			if err != nil {
+				fmt.Println("Error parsing hex color:", err)
+				continue
+			}
+			img.Set(int(x), int(y), multiplyColor(c, shade[y][x]))
+		}
+	}
+
+	return img, nil
+}
+
+// Hillshade возвращает множитель освещённости для каждой клетки; 1.0 — ровная поверхность.
+func Hillshade(w *world.World, opts ShadeOptions) ([][]float64, error) {
+	if !w.HasHeights() {
+		return nil, ErrNoHeights
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	az := opts.Azimuth * math.Pi / 180
+	alt := opts.Altitude * math.Pi / 180
+	// Север — вверх картинки, то есть в сторону уменьшения y
+	lx, ly, lz := math.Sin(az)*math.Cos(alt), -math.Cos(az)*math.Cos(alt), math.Sin(alt)
+	flat := lz
+
+	at := func(x, y int64) float64 {
+		x = min(max(x, 0), w.Width-1)
+		y = min(max(y, 0), w.Height-1)
+		return w.Heights[y][x] * opts.ZFactor
+	}
+
+	shade := make([][]float64, w.Height)
+	for y := int64(0); y < w.Height; y++ {
+		shade[y] = make([]float64, w.Width)
+		for x := int64(0); x < w.Width; x++ {
+			// Градиент по Хорну: взвешенные разности по окрестности 3×3
+			gx := ((at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1)) - (at(x-1, y-1) + 2*at(x-1, y) + at(x-1, y+1))) / 8
+			gy := ((at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1)) - (at(x-1, y-1) + 2*at(x, y-1) + at(x+1, y-1))) / 8
+
+			nl := math.Sqrt(gx*gx + gy*gy + 1)
+			lambert := math.Max(0, (-gx*lx-gy*ly+lz)/nl)
+
+			s := opts.Ambient + (1-opts.Ambient)*lambert/flat
+			if opts.AmbientOcclusion {
+				s *= 1 - opts.AOStrength*occlusion(at, x, y, opts.AORadius)
+			}
+			shade[y][x] = s
+		}
+	}
+
+	return shade, nil
+}
+
+var aoDirections = [8][2]int64{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+
+// occlusion — средний угол горизонта по восьми направлениям, нормированный к [0, 1].
+func occlusion(at func(x, y int64) float64, x, y int64, radius int) float64 {
+	h0 := at(x, y)
+	var total float64
+	for _, d := range aoDirections {
+		step := math.Hypot(float64(d[0]), float64(d[1]))
+		var horizon float64
+		for r := int64(1); r <= int64(radius); r++ {
+			dh := at(x+d[0]*r, y+d[1]*r) - h0
+			if dh > 0 {
+				horizon = math.Max(horizon, math.Atan(dh/(float64(r)*step)))
+			}
+		}
+		total += horizon / (math.Pi / 2)
+	}
+	return total / float64(len(aoDirections))
+}
+
+func multiplyColor(c color.Color, k float64) color.RGBA {
+	r, g, b, _ := c.RGBA()
+	scale := func(v uint32) uint8 {
+		return uint8(math.Max(0, math.Min(255, float64(v>>8)*k)))
+	}
+	return color.RGBA{R: scale(r), G: scale(g), B: scale(b), A: 255}
+}
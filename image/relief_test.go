@@ -0,0 +1,96 @@
+package image
+
+import (
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+func TestHillshadeFacesSun(t *testing.T) {
+	// Хребет посередине: западный склон смотрит на солнце (азимут 270), восточный — от него
+	heights := [][]float64{{0, 0.1, 0.2, 0.3, 0.2, 0.1, 0}}
+	matrix := [][]biome.Data{make([]biome.Data, 7)}
+	w := world.NewWorldWithHeights(matrix, heights, 1)
+
+	opts := NewShadeOptions()
+	opts.Azimuth, opts.ZFactor = 270, 5
+	shade, err := Hillshade(w, opts)
+	if err != nil {
+		t.Fatalf("Hillshade: %v", err)
+	}
+
+	if shade[0][1] <= 1 {
+		t.Errorf("sunlit slope shade = %v, want > 1", shade[0][1])
+	}
+	if shade[0][5] >= 1 {
+		t.Errorf("shadowed slope shade = %v, want < 1", shade[0][5])
+	}
+}
+
+func TestHillshadeKeepsZeroOptions(t *testing.T) {
+	// Хребет с запада на восток: при солнце с севера (азимут 0) южный склон
+	// полностью в тени, и без рассеянного света он чёрный
+	heights := [][]float64{{0}, {0.1}, {0.2}, {0.3}, {0.2}, {0.1}, {0}}
+	matrix := make([][]biome.Data, len(heights))
+	for y := range matrix {
+		matrix[y] = make([]biome.Data, 1)
+	}
+	w := world.NewWorldWithHeights(matrix, heights, 1)
+
+	opts := NewShadeOptions()
+	opts.Azimuth, opts.Ambient, opts.ZFactor = 0, 0, 50
+	shade, err := Hillshade(w, opts)
+	if err != nil {
+		t.Fatalf("Hillshade: %v", err)
+	}
+	if shade[1][0] <= 1 {
+		t.Errorf("north slope shade = %v, want > 1", shade[1][0])
+	}
+	if shade[5][0] != 0 {
+		t.Errorf("south slope shade = %v, want 0", shade[5][0])
+	}
+
+	opts.Altitude = 0
+	if _, err := Hillshade(w, opts); err == nil {
+		t.Error("zero sun altitude accepted")
+	}
+}
+
+func TestHillshadeAmbientOcclusion(t *testing.T) {
+	// Ровная площадка с колодцем посередине: затеняется только дно колодца
+	const size = 9
+	heights := make([][]float64, size)
+	matrix := make([][]biome.Data, size)
+	for y := range heights {
+		heights[y] = make([]float64, size)
+		matrix[y] = make([]biome.Data, size)
+		for x := range heights[y] {
+			heights[y][x] = 0.5
+		}
+	}
+	heights[4][4] = 0
+	w := world.NewWorldWithHeights(matrix, heights, 1)
+
+	opts := NewShadeOptions()
+	plain, err := Hillshade(w, opts)
+	if err != nil {
+		t.Fatalf("Hillshade: %v", err)
+	}
+	opts.AmbientOcclusion = true
+	occluded, err := Hillshade(w, opts)
+	if err != nil {
+		t.Fatalf("Hillshade with occlusion: %v", err)
+	}
+
+	if occluded[4][4] >= plain[4][4] {
+		t.Errorf("pit shade with occlusion = %v, want below %v", occluded[4][4], plain[4][4])
+	}
+	if occluded[0][0] != plain[0][0] {
+		t.Errorf("flat corner shade changed from %v to %v", plain[0][0], occluded[0][0])
+	}
+
+	opts.AORadius = 0
+	if _, err := Hillshade(w, opts); err == nil {
+		t.Error("zero occlusion radius accepted")
+	}
+}
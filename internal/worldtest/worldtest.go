@@ -0,0 +1,28 @@
+// Package worldtest — помощники для тестов, которым нужны маленькие миры.
+package worldtest
+
+import (
+	"fmt"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+// ParseGrid строит мир с сидом 1 по текстовой схеме, по символу на клетку,
+// например "~~.." с легендой {'~': море, '.': луга}. Символ без легенды
+// и строки разной длины — паника: схема пишется в самом тесте.
+func ParseGrid(legend map[rune]biome.Data, rows ...string) *world.World {
+	matrix := make([][]biome.Data, len(rows))
+	for y, row := range rows {
+		for _, ch := range row {
+			b, ok := legend[ch]
+			if !ok {
+				panic(fmt.Sprintf("worldtest: grid row %d: no biome for %q", y, ch))
+			}
+			matrix[y] = append(matrix[y], b)
+		}
+		if len(matrix[y]) != len(matrix[0]) {
+			panic(fmt.Sprintf("worldtest: grid row %d has %d cells, want %d", y, len(matrix[y]), len(matrix[0])))
+		}
+	}
+	return world.NewWorld(matrix, 1)
+}
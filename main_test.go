@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	stdimage "image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"tilemap-generator/mapgen/world"
+)
+
+func TestRunGenerateAndStats(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "w.tmap")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"generate", "-width", "24", "-height", "16", "-seed", "5", "-frequency", "0.05", "-out", out}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("generate exit %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "w.recipe.json")); err != nil {
+		t.Errorf("recipe was not saved: %v", err)
+	}
+
+	// Мир из файла и мир из рецепта совпадают
+	for _, src := range [][]string{{"-world", out}, {"-recipe", filepath.Join(dir, "w.recipe.json")}} {
+		stdout.Reset()
+		if code := run(append([]string{"stats", "-json"}, src...), &stdout, &stderr); code != exitOK {
+			t.Fatalf("stats %v exit %d: %s", src, code, stderr.String())
+		}
+		var stats world.Stats
+		if err := json.Unmarshal(stdout.Bytes(), &stats); err != nil {
+			t.Fatalf("stats JSON: %v", err)
+		}
+		var total int64
+		for _, b := range stats.Biomes {
+			total += b.Cells
+		}
+		if stats.Seed != 5 || total != 24*16 {
+			t.Errorf("stats %v: seed %d, %d cells", src, stats.Seed, total)
+		}
+	}
+}
+
+func TestRunGenerateFromHeightmap(t *testing.T) {
+	dir := t.TempDir()
+	// Склон с запада на восток: от дна океана до вершин
+	img := stdimage.NewGray16(stdimage.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			img.SetGray16(x, y, color.Gray16{Y: uint16(x * 65535 / 7)})
+		}
+	}
+	heightmap := filepath.Join(dir, "terrain.png")
+	file, err := os.Create(heightmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	out := filepath.Join(dir, "out", "w.tmap")
+	os.Mkdir(filepath.Dir(out), 0o755)
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"generate", "-width", "16", "-height", "8", "-seed", "5", "-heightmap", heightmap, "-out", out}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("generate exit %d: %s", code, stderr.String())
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var w world.World
+	if err := w.Load(f); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if w.Width != 16 || w.Height != 8 {
+		t.Fatalf("world %dx%d, want 16x8", w.Width, w.Height)
+	}
+	if west, east := w.Matrix[3][0], w.Matrix[3][15]; !west.IsWater() || east.Name != "Mounts" {
+		t.Errorf("west %s, east %s; want water and mountains", west.Name, east.Name)
+	}
+
+	// Рецепт в другом каталоге ссылается на ту же карту высот
+	stdout.Reset()
+	if code := run([]string{"stats", "-json", "-recipe", filepath.Join(dir, "out", "w.recipe.json")}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("stats from recipe exit %d: %s", code, stderr.String())
+	}
+	var stats world.Stats
+	if err := json.Unmarshal(stdout.Bytes(), &stats); err != nil {
+		t.Fatalf("stats JSON: %v", err)
+	}
+	if want := w.Stats(world.StatsOptions{}); stats.LandCells != want.LandCells || stats.Coastline != want.Coastline {
+		t.Errorf("world from recipe: %d land cells, coastline %d; want %d, %d", stats.LandCells, stats.Coastline, want.LandCells, want.Coastline)
+	}
+}
+
+func TestRunExportLDtkKeepsRecipeParams(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"generate", "-width", "12", "-height", "8", "-seed", "5", "-frequency", "0.05", "-offset-x", "7",
+		"-out", filepath.Join(dir, "w.png")}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("generate exit %d: %s", code, stderr.String())
+	}
+
+	out := filepath.Join(dir, "w.ldtk")
+	code = run([]string{"export", "-recipe", filepath.Join(dir, "w.recipe.json"), "-format", "ldtk", "-out", out}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("export exit %d: %s", code, stderr.String())
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var project struct {
+		Levels []struct {
+			FieldInstances []struct {
+				Identifier string  `json:"__identifier"`
+				Value      float64 `json:"__value"`
+			} `json:"fieldInstances"`
+		} `json:"levels"`
+	}
+	if err := json.Unmarshal(data, &project); err != nil {
+		t.Fatalf("ldtk JSON: %v", err)
+	}
+	if len(project.Levels) == 0 {
+		t.Fatal("no levels")
+	}
+	fields := make(map[string]float64)
+	for _, f := range project.Levels[0].FieldInstances {
+		fields[f.Identifier] = f.Value
+	}
+	for name, want := range map[string]float64{"seed": 5, "frequency": 0.05, "offset_x": 7, "offset_y": 0} {
+		if got, ok := fields[name]; !ok || got != want {
+			t.Errorf("field %s = %v (present %v), want %v", name, got, ok, want)
+		}
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"batch", "-width", "20", "-height", "12", "-frequency", "0.05", "-seeds", "1-3,9", "-thumb", "32", "-out", dir}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("batch exit %d: %s", code, stderr.String())
+	}
+	for _, name := range []string{"seed_1.png", "seed_3.png", "seed_9.png", "seed_9.recipe.json", "contact_sheet.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestRunSearch(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"search", "-width", "40", "-height", "30", "-frequency", "0.05", "-seeds", "1-40",
+		"-land", "0.2-", "-limit", "2", "-json"}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("search exit %d: %s", code, stderr.String())
+	}
+	var matches []struct {
+		Seed      int
+		LandRatio float64 `json:"land_ratio"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &matches); err != nil {
+		t.Fatalf("search JSON: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Seed >= matches[1].Seed || matches[0].LandRatio < 0.2 {
+		t.Errorf("matches %+v", matches)
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.tmap")
+	os.WriteFile(bad, []byte("junk"), 0o644)
+	empty := filepath.Join(dir, "empty.tmap")
+	if err := saveWorld(empty, &world.World{}); err != nil {
+		t.Fatal(err)
+	}
+	good := filepath.Join(dir, "good.tmap")
+	var log bytes.Buffer
+	if code := run([]string{"generate", "-width", "8", "-height", "8", "-out", good}, &log, &log); code != exitOK {
+		t.Fatalf("generate: exit %d (%s)", code, log.String())
+	}
+	tiles := filepath.Join(dir, "tiles")
+
+	for _, tc := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{"help"}, exitOK},
+		{[]string{"render", "-h"}, exitOK},
+		{[]string{"bogus"}, exitUsage},
+		{[]string{"generate", "-width", "wide"}, exitUsage},
+		{[]string{"generate", "-out", filepath.Join(dir, "x.jpg")}, exitUsage},
+		{[]string{"stats"}, exitUsage},
+		{[]string{"batch", "-seeds", "5-1"}, exitUsage},
+		{[]string{"search", "-seeds", "1-5"}, exitUsage},
+		{[]string{"search", "-land", "high"}, exitUsage},
+		{[]string{"export", "-world", bad, "-format", "tmx"}, exitUsage},
+		{[]string{"stats", "-world", bad}, exitInvalid},
+		{[]string{"export", "-world", empty, "-format", "ldtk", "-out", filepath.Join(dir, "x.ldtk")}, exitInvalid},
+		{[]string{"export", "-world", good, "-format", "tiles", "-min-zoom", "3", "-max-zoom", "1", "-out", tiles}, exitInvalid},
+		{[]string{"export", "-world", good, "-format", "tiles", "-downsample", "bogus", "-out", tiles}, exitInvalid},
+		{[]string{"export", "-world", good, "-format", "tiles", "-tile-size", "2", "-min-zoom", "1", "-max-zoom", "0", "-out", tiles}, exitInvalid},
+		{[]string{"export", "-world", empty, "-format", "obj", "-out", filepath.Join(dir, "x.obj")}, exitInvalid},
+		{[]string{"stats", "-world", filepath.Join(dir, "missing.tmap")}, exitFailure},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(tc.args, &stdout, &stderr); code != tc.code {
+			t.Errorf("%v: exit %d, want %d (%s)", tc.args, code, tc.code, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/recipe"
+	"tilemap-generator/mapgen/world"
+)
+
+// Пакетная генерация: один рецепт, много сидов. Миры не копятся в памяти —
+// каждый передаётся обработчику (сохранить, сделать миниатюру) и отбрасывается,
+// а наружу возвращается только краткая сводка.
+
+type Options struct {
+	// Сколько миров генерировать одновременно. Default: runtime.NumCPU().
+	Workers int
+}
+
+type BiomeCount struct {
+	biome.Data
+	Cells int64 `json:"cells"`
+}
+
+// Summary — ключевые показатели мира для подписи в контактном листе.
+type Summary struct {
+	Seed      int     `json:"seed"`
+	LandRatio float64 `json:"land_ratio"`
+	// По убыванию числа клеток.
+	Biomes []BiomeCount `json:"biomes"`
+}
+
+func Summarize(w *world.World) Summary {
+	counts := make(map[biome.Data]int64)
+	var land int64
+	w.Each(func(_ world.Point, b biome.Data) bool {
+		counts[b]++
+		if !b.IsWater() {
+			land++
+		}
+		return true
+	})
+
+	s := Summary{Seed: w.Seed}
+	if cells := w.Width * w.Height; cells > 0 {
+		s.LandRatio = float64(land) / float64(cells)
+	}
+	for _, b := range w.Palette() {
+		s.Biomes = append(s.Biomes, BiomeCount{b, counts[b]})
+	}
+	sort.SliceStable(s.Biomes, func(i, j int) bool { return s.Biomes[i].Cells > s.Biomes[j].Cells })
+
+	return s
+}
+
+// Run генерирует мир по рецепту для каждого сида. handle вызывается из рабочих
+// горутин с индексом сида в seeds, поэтому должен быть безопасен для параллельного
+// вызова. Первая ошибка останавливает выдачу новых сидов; сводки возвращаются
+// в порядке seeds.
+func Run(ctx context.Context, r *recipe.Recipe, seeds []int, opts Options, handle func(i int, w *world.World) error) ([]Summary, error) {
+	if err := r.Prepare(); err != nil {
+		return nil, err
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, len(seeds))
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	summaries := make([]Summary, len(seeds))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := generate(r, seeds[i], i, &summaries[i], handle); err != nil {
+					cancel(err)
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range seeds {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := context.Cause(ctx); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+func generate(r *recipe.Recipe, seed, i int, summary *Summary, handle func(int, *world.World) error) error {
+	rc := *r
+	rc.Params.Seed = seed
+	w, err := rc.Generate()
+	if err != nil {
+		return fmt.Errorf("batch: seed %d: %w", seed, err)
+	}
+	*summary = Summarize(w)
+	if handle != nil {
+		if err := handle(i, w); err != nil {
+			return fmt.Errorf("batch: seed %d: %w", seed, err)
+		}
+	}
+	return nil
+}
+
+// MaxSeeds — сколько сидов можно перечислить в ParseSeeds.
+const MaxSeeds = 1_000_000
+
+// ParseSeeds разбирает список сидов и диапазонов через запятую: "1-16", "3,7,42-44".
+// Нулевой сид запрещён: генератор заменил бы его сидом по времени.
+func ParseSeeds(spec string) ([]int, error) {
+	var seeds []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		// Минус в начале — знак числа, а не диапазон
+		from, to := part, part
+		if i := strings.Index(part[1:], "-"); i >= 0 {
+			from, to = part[:i+1], part[i+2:]
+		}
+		lo, errLo := strconv.Atoi(strings.TrimSpace(from))
+		hi, errHi := strconv.Atoi(strings.TrimSpace(to))
+		if errLo != nil || errHi != nil {
+			return nil, fmt.Errorf("batch: invalid seed %q", part)
+		}
+		if lo > hi {
+			return nil, fmt.Errorf("batch: empty seed range %q", part)
+		}
+		// Разность в uint64 не переполняется даже для диапазона от MinInt до MaxInt
+		if uint64(hi)-uint64(lo) >= uint64(MaxSeeds-len(seeds)) {
+			return nil, fmt.Errorf("batch: more than %d seeds", MaxSeeds)
+		}
+		for s := lo; ; s++ {
+			if s == 0 {
+				return nil, errors.New("batch: seed 0 is reserved for a time-based seed")
+			}
+			seeds = append(seeds, s)
+			// Условие s <= hi при hi = MaxInt не стало бы ложным
+			if s == hi {
+				break
+			}
+		}
+	}
+	if len(seeds) == 0 {
+		return nil, errors.New("batch: no seeds")
+	}
+	return seeds, nil
+}
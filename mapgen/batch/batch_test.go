@@ -0,0 +1,89 @@
+package batch
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/generator"
+	"tilemap-generator/mapgen/recipe"
+	"tilemap-generator/mapgen/world"
+)
+
+func TestParseSeeds(t *testing.T) {
+	seeds, err := ParseSeeds("3, 7,10-12,-2--1")
+	if err != nil {
+		t.Fatalf("ParseSeeds: %v", err)
+	}
+	if want := []int{3, 7, 10, 11, 12, -2, -1}; !reflect.DeepEqual(seeds, want) {
+		t.Errorf("seeds = %v, want %v", seeds, want)
+	}
+
+	// Последний сид диапазона до MaxInt не переполняет счётчик
+	maxInt := strconv.Itoa(math.MaxInt)
+	seeds, err = ParseSeeds(strconv.Itoa(math.MaxInt-1) + "-" + maxInt)
+	if err != nil || !reflect.DeepEqual(seeds, []int{math.MaxInt - 1, math.MaxInt}) {
+		t.Errorf("range up to MaxInt = %v, %v", seeds, err)
+	}
+
+	for _, spec := range []string{"", "a-b", "5-1", "-1-1", "1-" + maxInt, strconv.Itoa(math.MinInt) + "-" + maxInt, "1-600000,700000-1200000"} {
+		if _, err := ParseSeeds(spec); err == nil {
+			t.Errorf("ParseSeeds(%q): expected error", spec)
+		}
+	}
+}
+
+func TestRunMatchesSequentialGeneration(t *testing.T) {
+	set := biome.NewSet()
+	set.Add(0, 0.5, biome.Data{Name: biome.WaterGroup, NameRU: "Океан", Color: "#4292c4"})
+	set.Add(0.5, 1, biome.Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"})
+	r := recipe.New(world.Config{Width: 24, Height: 16}, generator.WorldGeneratorParams{Frequency: 0.08}, set)
+
+	seeds := []int{4, 8, 15, 16, 23, 42}
+	var handled atomic.Int32
+	summaries, err := Run(context.Background(), r, seeds, Options{Workers: 3}, func(i int, w *world.World) error {
+		if w.Seed != seeds[i] {
+			t.Errorf("handle(%d) got seed %d", i, w.Seed)
+		}
+		handled.Add(1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if handled.Load() != int32(len(seeds)) {
+		t.Errorf("handled %d worlds, want %d", handled.Load(), len(seeds))
+	}
+
+	for i, seed := range seeds {
+		rc := *r
+		rc.Params.Seed = seed
+		w, err := rc.Generate()
+		if err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+		if want := Summarize(w); !reflect.DeepEqual(summaries[i], want) {
+			t.Errorf("seed %d: summary %+v, want %+v", seed, summaries[i], want)
+		}
+	}
+	if r.Params.Seed != 0 {
+		t.Errorf("Run changed the recipe seed to %d", r.Params.Seed)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	sea := biome.Data{Name: biome.WaterGroup, Color: "#0000ff"}
+	field := biome.Data{Name: "Fields", Color: "#00ff00"}
+	w := world.NewWorld([][]biome.Data{{sea, field, field}, {sea, field, field}}, 1)
+
+	s := Summarize(w)
+	if s.LandRatio != 4.0/6 {
+		t.Errorf("land ratio %v, want 4/6", s.LandRatio)
+	}
+	if len(s.Biomes) != 2 || s.Biomes[0].Data != field || s.Biomes[0].Cells != 4 {
+		t.Errorf("biomes %+v, want fields first with 4 cells", s.Biomes)
+	}
+}
@@ -0,0 +1,252 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+// Range — допустимый интервал доли клеток [0, 1]. Max = nil — без верхней границы,
+// а {"max": 0} требует, чтобы клеток не было вовсе.
+type Range struct {
+	Min float64  `json:"min"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+func (r Range) contains(v, slack float64) bool {
+	return v >= r.Min-slack && (r.Max == nil || v <= *r.Max+slack)
+}
+
+// score — насколько близко значение к середине интервала: 1 в центре, 0 на границе.
+// У интервала без верхней границы любое подходящее значение одинаково хорошо.
+func (r Range) score(v float64) float64 {
+	if r.Max == nil || *r.Max <= r.Min {
+		return 1
+	}
+	half := (*r.Max - r.Min) / 2
+	return clamp01(1 - math.Abs(v-r.Min-half)/half)
+}
+
+func (r Range) String() string {
+	if r.Max == nil {
+		return fmt.Sprintf("%g..", r.Min)
+	}
+	return fmt.Sprintf("%g..%g", r.Min, *r.Max)
+}
+
+// RegionConstraint требует не меньше Count связных областей группы Group
+// (соседство по сторонам) размером от MinCells клеток, например горный хребет.
+type RegionConstraint struct {
+	Group    string `json:"group"`
+	MinCells int64  `json:"min_cells"`
+	// Default: 1.
+	Count int `json:"count,omitempty"`
+}
+
+// AreaConstraint запрещает клетки группы Group в круге радиуса Radius вокруг
+// клетки (X, Y), например воду рядом с точкой появления игрока.
+type AreaConstraint struct {
+	X      int64  `json:"x"`
+	Y      int64  `json:"y"`
+	Radius int64  `json:"radius"`
+	Group  string `json:"group"`
+}
+
+// Constraints — декларативные условия поиска сидов; пустые поля не проверяются.
+//
+//	{
+//	  "land": {"min": 0.35, "max": 0.45},
+//	  "groups": {"Mounts": {"min": 0.05}},
+//	  "regions": [{"group": "Mounts", "min_cells": 500}],
+//	  "clear": [{"x": 500, "y": 500, "radius": 20, "group": "Liquid"}]
+//	}
+//
+// Группа — поле Name биома.
+type Constraints struct {
+	// Доля суши, то есть всех групп кроме biome.WaterGroup.
+	Land *Range `json:"land,omitempty"`
+	// Доли отдельных групп.
+	Groups  map[string]Range   `json:"groups,omitempty"`
+	Regions []RegionConstraint `json:"regions,omitempty"`
+	Clear   []AreaConstraint   `json:"clear,omitempty"`
+}
+
+func LoadConstraints(path string) (Constraints, error) {
+	var c Constraints
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("batch: constraints %s: %w", path, err)
+	}
+	return c, c.Validate()
+}
+
+func (c Constraints) Validate() error {
+	ranges := make(map[string]Range, len(c.Groups)+1)
+	if c.Land != nil {
+		ranges["land"] = *c.Land
+	}
+	for name, r := range c.Groups {
+		ranges[name] = r
+	}
+	for name, r := range ranges {
+		if r.Min < 0 || r.Min > 1 || (r.Max != nil && (*r.Max < r.Min || *r.Max > 1)) {
+			return fmt.Errorf("batch: constraint %s: invalid range %v", name, r)
+		}
+	}
+	for _, r := range c.Regions {
+		if r.Group == "" || r.MinCells <= 0 || r.Count < 0 {
+			return fmt.Errorf("batch: region constraint needs a group and positive min_cells, got %+v", r)
+		}
+	}
+	for _, a := range c.Clear {
+		if a.Group == "" || a.Radius < 0 {
+			return fmt.Errorf("batch: clear constraint needs a group and non-negative radius, got %+v", a)
+		}
+	}
+	if c.Land == nil && len(c.Groups) == 0 && len(c.Regions) == 0 && len(c.Clear) == 0 {
+		return errors.New("batch: no constraints")
+	}
+	return nil
+}
+
+// checkArea проверяет, что центры кругов Clear лежат внутри мира: круг снаружи
+// не содержит ни одной клетки и прошёл бы проверку при любом сиде.
+func (c Constraints) checkArea(config world.Config) error {
+	for _, a := range c.Clear {
+		if a.X < 0 || a.Y < 0 || a.X >= config.Width || a.Y >= config.Height {
+			return fmt.Errorf("batch: clear constraint at (%d, %d) is outside the %dx%d world", a.X, a.Y, config.Width, config.Height)
+		}
+	}
+	return nil
+}
+
+// Допуски проверки по превью: оно лишь отсеивает заведомо неподходящие сиды,
+// поэтому условия ослаблены, а окончательно всё проверяется на полном мире.
+const (
+	previewSlack   = 0.02
+	previewRegionK = 0.5
+)
+
+// Evaluate проверяет мир и возвращает оценку в [0, 1] — среднее по условиям:
+// насколько доли близки к середине интервалов, области превышают порог
+// и запретные клетки далеки от круга.
+func (c Constraints) Evaluate(w *world.World) (score float64, ok bool) {
+	return c.evaluate(w, 1)
+}
+
+// evaluate при step > 1 считает w превью, где клетка заменяет step×step клеток мира.
+func (c Constraints) evaluate(w *world.World, step int64) (float64, bool) {
+	preview := step > 1
+	slack := 0.0
+	if preview {
+		slack = previewSlack
+	}
+
+	var scores []float64
+	if c.Land != nil || len(c.Groups) > 0 {
+		groups := make(map[string]int64)
+		var land int64
+		w.Each(func(_ world.Point, b biome.Data) bool {
+			groups[b.Name]++
+			if !b.IsWater() {
+				land++
+			}
+			return true
+		})
+		total := float64(w.Width * w.Height)
+
+		if c.Land != nil {
+			v := float64(land) / total
+			if !c.Land.contains(v, slack) {
+				return 0, false
+			}
+			scores = append(scores, c.Land.score(v))
+		}
+		for name, r := range c.Groups {
+			v := float64(groups[name]) / total
+			if !r.contains(v, slack) {
+				return 0, false
+			}
+			scores = append(scores, r.score(v))
+		}
+	}
+
+	var regions *world.Regions
+	if len(c.Regions) > 0 {
+		regions = w.Label(world.LabelOptions{Class: world.ByGroup})
+	}
+	for _, r := range c.Regions {
+		sizes := regionSizes(regions, r.Group)
+		count := max(r.Count, 1)
+		if len(sizes) < count {
+			return 0, false
+		}
+		size := sizes[count-1] * step * step
+		need := float64(r.MinCells)
+		if preview {
+			need *= previewRegionK
+		}
+		if float64(size) < need {
+			return 0, false
+		}
+		scores = append(scores, 1-float64(r.MinCells)/float64(max(size, r.MinCells)))
+	}
+
+	// Узкий круг на превью может проскочить между выборками, поэтому только по полному миру
+	if !preview {
+		for _, a := range c.Clear {
+			d := nearestCell(w, a)
+			if d <= float64(a.Radius) {
+				return 0, false
+			}
+			scores = append(scores, clamp01((d-float64(a.Radius))/float64(max(a.Radius, 1))))
+		}
+	}
+
+	if len(scores) == 0 {
+		return 1, true
+	}
+	sum := 0.0
+	for _, s := range scores {
+		sum += s
+	}
+	return sum / float64(len(scores)), true
+}
+
+// regionSizes возвращает размеры связных по сторонам областей группы по убыванию.
+func regionSizes(regions *world.Regions, group string) []int64 {
+	var sizes []int64
+	for _, r := range regions.List {
+		if r.Class == group {
+			sizes = append(sizes, r.Size)
+		}
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] > sizes[j] })
+	return sizes
+}
+
+// nearestCell — расстояние от центра круга до ближайшей клетки группы в пределах
+// двух радиусов; если таких нет, возвращается 2·Radius+1.
+func nearestCell(w *world.World, a AreaConstraint) float64 {
+	reach := 2*a.Radius + 1
+	best := float64(reach)
+	w.EachIn(world.Point{X: a.X - reach, Y: a.Y - reach}, world.Point{X: a.X + reach + 1, Y: a.Y + reach + 1}, func(p world.Point, b biome.Data) bool {
+		if b.Name == a.Group {
+			best = math.Min(best, math.Hypot(float64(p.X-a.X), float64(p.Y-a.Y)))
+		}
+		return true
+	})
+	return best
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,132 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"tilemap-generator/mapgen/recipe"
+	"tilemap-generator/mapgen/world"
+)
+
+type SearchOptions struct {
+	// Сколько миров проверять одновременно. Default: runtime.NumCPU().
+	Workers int
+	// Сколько подходящих сидов найти. Default: 10.
+	Limit int
+	// Шаг превью: сначала строится каждая Preview-я клетка мира, и полный мир
+	// генерируется только если превью прошло ослабленные условия. Превью приближённое:
+	// сид у самой границы условий может быть отсеян. 0 или 1 — без превью. С эскизом превью не строится: GenerateChunk его не применяет.
+	Preview int64
+}
+
+type Match struct {
+	Summary
+	Score float64 `json:"score"`
+}
+
+// Search проверяет сиды параллельно и возвращает первые Limit подходящих
+// в порядке seeds — результат не зависит от числа потоков.
+func Search(ctx context.Context, r *recipe.Recipe, seeds []int, c Constraints, opts SearchOptions) ([]Match, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	// По карте высот каждый сид даёт один и тот же мир
+	if r.Heightmap != "" {
+		return nil, errors.New("batch: search does not support recipes with a heightmap")
+	}
+	if err := r.Prepare(); err != nil {
+		return nil, err
+	}
+	if err := c.checkArea(r.Config); err != nil {
+		return nil, err
+	}
+	g, err := r.Generator()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+	step := opts.Preview
+	if r.Params.Sketch != nil {
+		step = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Результаты собираются по индексам; как только в проверенном без пропусков
+	// начале списка набирается Limit совпадений, остальные сиды не нужны
+	var (
+		mu      sync.Mutex
+		results = make([]*Match, len(seeds))
+		done    = make([]bool, len(seeds))
+		prefix  int
+		found   int
+	)
+	record := func(i int, m *Match) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[i], done[i] = m, true
+		for prefix < len(seeds) && done[prefix] {
+			if results[prefix] != nil {
+				found++
+			}
+			prefix++
+		}
+		if found >= opts.Limit {
+			cancel()
+		}
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(opts.Workers, len(seeds)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(next.Add(1) - 1)
+				if i >= len(seeds) {
+					return
+				}
+
+				params := r.Params
+				params.Seed = seeds[i]
+				if step > 1 {
+					width := (r.Config.Width + step - 1) / step
+					height := (r.Config.Height + step - 1) / step
+					if _, ok := c.evaluate(g.GenerateChunk(params, world.Point{}, width, height, step), step); !ok {
+						record(i, nil)
+						continue
+					}
+				}
+
+				w := g.Generate(params)
+				if score, ok := c.Evaluate(w); ok {
+					record(i, &Match{Summary: Summarize(w), Score: score})
+				} else {
+					record(i, nil)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var matches []Match
+	for i := 0; i < prefix && len(matches) < opts.Limit; i++ {
+		if results[i] != nil {
+			matches = append(matches, *results[i])
+		}
+	}
+	// Отмена снаружи, а не по набранному Limit, — ошибка вместе с тем, что успели найти
+	if err := ctx.Err(); err != nil && len(matches) < opts.Limit && prefix < len(seeds) {
+		return matches, err
+	}
+	return matches, nil
+}
@@ -0,0 +1,156 @@
+package batch
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"tilemap-generator/internal/worldtest"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/generator"
+	"tilemap-generator/mapgen/recipe"
+	"tilemap-generator/mapgen/world"
+)
+
+var (
+	sea   = biome.Data{Name: biome.WaterGroup, NameRU: "Океан", Color: "#4292c4"}
+	field = biome.Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"}
+	mount = biome.Data{Name: "Mounts", NameRU: "Горы", Color: "#808080"}
+)
+
+// parseWorld строит мир по схеме: '~' — вода, '.' — луга, '^' — горы.
+func parseWorld(rows ...string) *world.World {
+	return worldtest.ParseGrid(map[rune]biome.Data{'~': sea, '.': field, '^': mount}, rows...)
+}
+
+func TestConstraintsEvaluate(t *testing.T) {
+	w := parseWorld(
+		"~~~~....",
+		"~~~..^^.",
+		"~~...^^.",
+		"~~^.....",
+	)
+
+	for _, tc := range []struct {
+		name string
+		c    Constraints
+		ok   bool
+	}{
+		{"land in range", Constraints{Land: &Range{Min: 0.5, Max: bound(0.8)}}, true},
+		{"land too low", Constraints{Land: &Range{Min: 0.8}}, false},
+		{"group share", Constraints{Groups: map[string]Range{"Mounts": {Min: 0.1, Max: bound(0.2)}}}, true},
+		{"no water", Constraints{Groups: map[string]Range{biome.WaterGroup: {Max: bound(0)}}}, false},
+		{"no lava", Constraints{Groups: map[string]Range{"Lava": {Max: bound(0)}}}, true},
+		{"range of 4", Constraints{Regions: []RegionConstraint{{Group: "Mounts", MinCells: 4}}}, true},
+		{"range of 5", Constraints{Regions: []RegionConstraint{{Group: "Mounts", MinCells: 5}}}, false},
+		{"two ranges", Constraints{Regions: []RegionConstraint{{Group: "Mounts", MinCells: 1, Count: 2}}}, true},
+		{"dry spawn", Constraints{Clear: []AreaConstraint{{X: 6, Y: 3, Radius: 3, Group: biome.WaterGroup}}}, true},
+		{"wet spawn", Constraints{Clear: []AreaConstraint{{X: 4, Y: 2, Radius: 3, Group: biome.WaterGroup}}}, false},
+	} {
+		if _, ok := tc.c.Evaluate(w); ok != tc.ok {
+			t.Errorf("%s: ok = %v, want %v", tc.name, ok, tc.ok)
+		}
+	}
+
+	// Середина интервала оценивается выше края
+	centre, _ := Constraints{Land: &Range{Min: 0.5, Max: bound(0.75)}}.Evaluate(w)
+	edge, _ := Constraints{Land: &Range{Min: 0.6, Max: bound(0.9)}}.Evaluate(w)
+	if centre <= edge {
+		t.Errorf("centre score %v <= edge score %v", centre, edge)
+	}
+}
+
+func TestSearchIsDeterministic(t *testing.T) {
+	set := biome.NewSet()
+	set.Add(0, 0.5, sea)
+	set.Add(0.5, 0.7, field)
+	set.Add(0.7, 1, mount)
+	r := recipe.New(world.Config{Width: 64, Height: 48}, generator.WorldGeneratorParams{Frequency: 0.05}, set)
+
+	seeds, _ := ParseSeeds("1-60")
+	c := Constraints{
+		Land:    &Range{Min: 0.4, Max: bound(0.7)},
+		Regions: []RegionConstraint{{Group: "Mounts", MinCells: 30}},
+	}
+
+	var want []Match
+	for _, seed := range seeds {
+		rc := *r
+		rc.Params.Seed = seed
+		w, _ := rc.Generate()
+		if score, ok := c.Evaluate(w); ok && len(want) < 3 {
+			want = append(want, Match{Summary: Summarize(w), Score: score})
+		}
+	}
+	if len(want) == 0 {
+		t.Fatal("no seed matches; loosen the constraints")
+	}
+
+	for _, workers := range []int{1, 4} {
+		got, err := Search(context.Background(), r, seeds, c, SearchOptions{Workers: workers, Limit: 3})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("workers %d: got %v, want %v", workers, seedsOf(got), seedsOf(want))
+		}
+	}
+
+}
+
+func TestSearchRejectsAreaOutsideWorld(t *testing.T) {
+	set := biome.NewSet()
+	set.Add(0, 0.5, sea)
+	set.Add(0.5, 1, field)
+	r := recipe.New(world.Config{Width: 16, Height: 16}, generator.WorldGeneratorParams{Frequency: 0.05}, set)
+
+	for _, a := range []AreaConstraint{{X: 16, Y: 0}, {X: 0, Y: -1}, {X: 500, Y: 500}} {
+		a.Group, a.Radius = biome.WaterGroup, 2
+		c := Constraints{Clear: []AreaConstraint{a}}
+		if _, err := Search(context.Background(), r, []int{1}, c, SearchOptions{}); err == nil {
+			t.Errorf("(%d, %d): expected an error", a.X, a.Y)
+		}
+	}
+}
+
+func TestSearchPreviewKeepsSeeds(t *testing.T) {
+	set := biome.NewSet()
+	set.Add(0, 0.5, sea)
+	set.Add(0.5, 0.7, field)
+	set.Add(0.7, 1, mount)
+	r := recipe.New(world.Config{Width: 64, Height: 48}, generator.WorldGeneratorParams{Frequency: 0.05}, set)
+
+	seeds, _ := ParseSeeds("1-60")
+	c := Constraints{
+		Land:    &Range{Min: 0.4, Max: bound(0.7)},
+		Regions: []RegionConstraint{{Group: "Mounts", MinCells: 30}},
+	}
+
+	full, err := Search(context.Background(), r, seeds, c, SearchOptions{Limit: len(seeds)})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(full) == 0 {
+		t.Fatal("no seed matches; loosen the constraints")
+	}
+	for _, preview := range []int64{2, 4} {
+		got, err := Search(context.Background(), r, seeds, c, SearchOptions{Limit: len(seeds), Preview: preview})
+		if err != nil {
+			t.Fatalf("Search with preview %d: %v", preview, err)
+		}
+		if !reflect.DeepEqual(seedsOf(got), seedsOf(full)) {
+			t.Errorf("preview %d: got seeds %v, want %v", preview, seedsOf(got), seedsOf(full))
+		}
+	}
+}
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+func seedsOf(matches []Match) []int {
+	var seeds []int
+	for _, m := range matches {
+		seeds = append(seeds, m.Seed)
+	}
+	return seeds
+}
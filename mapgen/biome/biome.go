@@ -4,13 +4,22 @@ import "math"
 
 type WorldBiome struct {
 	LowerBound, UpperBound float64
-	Data                   Data
+	// Приоритет при пересечении диапазонов в Set: больший перекрывает меньший.
+	Priority int
+	Data     Data
 }
 
 type Data struct {
-	Name   string
-	NameRU string
-	Color  string
+	Name   string `json:"name"`
+	NameRU string `json:"name_ru"`
+	Color  string `json:"color"`
+}
+
+// WaterGroup — группа (Name) водных биомов, остальные считаются сушей.
+const WaterGroup = "Liquid"
+
+func (d Data) IsWater() bool {
+	return d.Name == WaterGroup
 }
 
 func NewWorldBiome(lowerBound, upperBound float64, data Data) *WorldBiome {
@@ -20,3 +29,18 @@ func NewWorldBiome(lowerBound, upperBound float64, data Data) *WorldBiome {
 		Data:       data,
 	}
 }
+
+// Lookup возвращает биом с наибольшим приоритетом, в диапазон которого попадает
+// высота, или nil. Верхняя граница 1.0 включается в последний диапазон, чтобы
+// пик карты не проваливался в разрыв. Общий для Set.Pick и генератора.
+func Lookup(biomes []WorldBiome, height float64) *WorldBiome {
+	var best *WorldBiome
+	for i := range biomes {
+		b := &biomes[i]
+		inRange := (height >= b.LowerBound && height < b.UpperBound) || (height >= 1 && b.UpperBound >= 1)
+		if inRange && (best == nil || b.Priority > best.Priority) {
+			best = b
+		}
+	}
+	return best
+}
@@ -0,0 +1,346 @@
+package biome
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+)
+
+// FileVersion — текущая версия формата файла биомов.
+const FileVersion = 1
+
+// Пример файла:
+//
+//	{
+//	  "version": 1,
+//	  "fallback": {"name": "Liquid", "name_ru": "Океан", "color": "#4292c4"},
+//	  "biomes": [
+//	    {"lower": 0, "upper": 0.08, "name": "Liquid", "name_ru": "Океан", "color": "#4292c4"},
+//	    {"lower": 0.08, "upper": 1, "priority": 1, "name": "Fields", "name_ru": "Луга", "color": "#5dbc21"}
+//	  ]
+//	}
+//
+// Новые атрибуты биома добавляются полями в Data с json-тегом; неизвестные
+// ключи считаются ошибкой, чтобы опечатки не терялись молча.
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// FileError — ошибка разбора файла биомов с привязкой к строке.
+// Line равен 0, если ошибка относится к набору целиком (разрывы, пересечения).
+type FileError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "<input>"
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+type fileBiome struct {
+	Lower    *float64 `json:"lower"`
+	Upper    *float64 `json:"upper"`
+	Priority int      `json:"priority,omitempty"`
+	Data
+}
+
+type setFile struct {
+	Version  int         `json:"version"`
+	Fallback *Data       `json:"fallback,omitempty"`
+	Biomes   []fileBiome `json:"biomes"`
+}
+
+func LoadSet(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := parseSet(data)
+	if err != nil {
+		var fe *FileError
+		if errors.As(err, &fe) {
+			fe.Path = path
+		}
+		return nil, err
+	}
+
+	return set, nil
+}
+
+func ReadSet(r io.Reader) (*Set, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSet(data)
+}
+
+func SaveSet(path string, set *Set) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return WriteSet(file, set)
+}
+
+// WriteSet пишет набор по одному биому на строку, чтобы правки дизайнеров
+// давали короткие диффы.
+func WriteSet(w io.Writer, set *Set) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "{\n  \"version\": %d,\n", FileVersion)
+	if set.Fallback != nil {
+		fallback, err := json.Marshal(set.Fallback)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "  \"fallback\": %s,\n", fallback)
+	}
+
+	buf.WriteString("  \"biomes\": [")
+	for i, b := range set.Biomes {
+		lower, upper := b.LowerBound, b.UpperBound
+		entry, err := json.Marshal(fileBiome{Lower: &lower, Upper: &upper, Priority: b.Priority, Data: b.Data})
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("\n    ")
+		buf.Write(entry)
+	}
+	buf.WriteString("\n  ]\n}\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func parseSet(data []byte) (*Set, error) {
+	var file setFile
+	var biomeLines []int
+
+	fields, err := splitObject(data, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range fields {
+		switch f.key {
+		case "version":
+			if err := decodeStrict(f.value, &file.Version); err != nil {
+				return nil, lineError(data, f.offset, err)
+			}
+		case "fallback":
+			if err := decodeStrict(f.value, &file.Fallback); err != nil {
+				return nil, lineError(data, f.offset, err)
+			}
+		case "biomes":
+			elems, err := splitArray(data, f.offset)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range elems {
+				var b fileBiome
+				if err := decodeStrict(e.value, &b); err != nil {
+					return nil, lineError(data, e.offset, err)
+				}
+				file.Biomes = append(file.Biomes, b)
+				biomeLines = append(biomeLines, lineAt(data, e.offset))
+			}
+		default:
+			return nil, lineError(data, f.offset, fmt.Errorf("unknown key %q", f.key))
+		}
+	}
+
+	if file.Version != FileVersion {
+		return nil, &FileError{Line: max(1, fieldLine(data, fields, "version")), Err: fmt.Errorf("unsupported version %d, expected %d", file.Version, FileVersion)}
+	}
+
+	set := NewSet()
+	if file.Fallback != nil {
+		if err := validateData(*file.Fallback); err != nil {
+			return nil, &FileError{Line: fieldLine(data, fields, "fallback"), Err: fmt.Errorf("fallback: %w", err)}
+		}
+		set.SetFallback(*file.Fallback)
+	}
+
+	for i, b := range file.Biomes {
+		line := biomeLines[i]
+		if b.Lower == nil || b.Upper == nil {
+			return nil, &FileError{Line: line, Err: errors.New("biome requires both \"lower\" and \"upper\"")}
+		}
+		if *b.Lower < 0 || *b.Upper > 1 || *b.Lower >= *b.Upper {
+			return nil, &FileError{Line: line, Err: fmt.Errorf("bounds [%v, %v) must satisfy 0 <= lower < upper <= 1", *b.Lower, *b.Upper)}
+		}
+		if err := validateData(b.Data); err != nil {
+			return nil, &FileError{Line: line, Err: err}
+		}
+		set.AddWithPriority(*b.Lower, *b.Upper, b.Priority, b.Data)
+	}
+
+	if err := set.Validate(); err != nil {
+		return nil, &FileError{Err: err}
+	}
+
+	return set, nil
+}
+
+func validateData(d Data) error {
+	if d.Name == "" {
+		return errors.New("biome requires a \"name\"")
+	}
+	if !hexColorPattern.MatchString(d.Color) {
+		return fmt.Errorf("biome %q: color %q is not in #rrggbb form", d.Name, d.Color)
+	}
+	return nil
+}
+
+type jsonField struct {
+	key    string
+	offset int64
+	value  json.RawMessage
+}
+
+// splitObject разбирает объект верхнего уровня, начинающийся с offset,
+// и запоминает, где в файле начинается значение каждого ключа.
+func splitObject(data []byte, offset int64) ([]jsonField, error) {
+	dec := json.NewDecoder(bytes.NewReader(data[offset:]))
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, streamError(data, offset, dec, err)
+	}
+
+	var fields []jsonField
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, streamError(data, offset, dec, err)
+		}
+		key, _ := tok.(string)
+
+		var value json.RawMessage
+		start := offset + dec.InputOffset()
+		if err := dec.Decode(&value); err != nil {
+			return nil, streamError(data, offset, dec, err)
+		}
+		fields = append(fields, jsonField{key: key, offset: skipSeparators(data, start), value: value})
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, streamError(data, offset, dec, err)
+	}
+
+	return fields, nil
+}
+
+func splitArray(data []byte, offset int64) ([]jsonField, error) {
+	dec := json.NewDecoder(bytes.NewReader(data[offset:]))
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, streamError(data, offset, dec, err)
+	}
+
+	var elems []jsonField
+	for dec.More() {
+		var value json.RawMessage
+		start := offset + dec.InputOffset()
+		if err := dec.Decode(&value); err != nil {
+			return nil, streamError(data, offset, dec, err)
+		}
+		elems = append(elems, jsonField{offset: skipSeparators(data, start), value: value})
+	}
+
+	return elems, nil
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return nil
+}
+
+func decodeStrict(raw json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// lineError переводит смещение в номер строки. Для синтаксических ошибок и
+// ошибок типов берётся точное смещение из самой ошибки.
+func lineError(data []byte, offset int64, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset += syntaxErr.Offset - 1
+	case errors.As(err, &typeErr):
+		offset += typeErr.Offset - 1
+		err = fmt.Errorf("field %q: expected %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+	}
+
+	return &FileError{Line: lineAt(data, offset), Err: err}
+}
+
+// streamError — то же, что lineError, но для ошибок потокового декодера,
+// у которого смещения отсчитываются от начала разбираемого фрагмента.
+func streamError(data []byte, base int64, dec *json.Decoder, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return lineError(data, base, err)
+	}
+	return &FileError{Line: lineAt(data, base+dec.InputOffset()), Err: err}
+}
+
+func skipSeparators(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func fieldLine(data []byte, fields []jsonField, key string) int {
+	for _, f := range fields {
+		if f.key == key {
+			return lineAt(data, f.offset)
+		}
+	}
+	return 0
+}
+
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
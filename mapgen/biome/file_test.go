@@ -0,0 +1,100 @@
+package biome
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteReadSetRoundTrip(t *testing.T) {
+	set := NewSet()
+	set.Add(0, 0.3, Data{Name: "Liquid", NameRU: "Океан", Color: "#4292c4"})
+	set.AddWithPriority(0.3, 1, 2, Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"})
+	set.SetFallback(Data{Name: "Liquid", NameRU: "Океан", Color: "#4292c4"})
+
+	var buf bytes.Buffer
+	if err := WriteSet(&buf, set); err != nil {
+		t.Fatalf("WriteSet: %v", err)
+	}
+
+	got, err := ReadSet(&buf)
+	if err != nil {
+		t.Fatalf("ReadSet: %v", err)
+	}
+	if len(got.Biomes) != len(set.Biomes) || got.Fallback == nil || *got.Fallback != *set.Fallback {
+		t.Fatalf("round trip mismatch: %+v", got)
+	}
+	for i := range set.Biomes {
+		if got.Biomes[i] != set.Biomes[i] {
+			t.Errorf("biome %d = %+v, want %+v", i, got.Biomes[i], set.Biomes[i])
+		}
+	}
+}
+
+func TestReadSetErrorLines(t *testing.T) {
+	for _, tc := range []struct {
+		name, input string
+		line        int
+		msg         string
+	}{
+		{
+			name: "bad color",
+			input: `{
+  "version": 1,
+  "biomes": [
+    {"lower": 0, "upper": 0.5, "name": "Liquid", "color": "#4292c4"},
+    {"lower": 0.5, "upper": 1, "name": "Fields", "color": "green"}
+  ]
+}`,
+			line: 5,
+			msg:  "not in #rrggbb form",
+		},
+		{
+			name: "unknown field",
+			input: `{
+  "version": 1,
+  "biomes": [
+    {"lower": 0, "upper": 1, "name": "Liquid", "colour": "#4292c4"}
+  ]
+}`,
+			line: 4,
+			msg:  "unknown field",
+		},
+		{
+			name: "syntax",
+			input: `{
+  "version": 1,
+  "biomes": [
+    {"lower": 0, "upper": 1, "name": "Liquid" "color": "#4292c4"}
+  ]
+}`,
+			line: 4,
+			msg:  "invalid character",
+		},
+		{
+			name: "wrong type",
+			input: `{
+  "version": 1,
+  "biomes": [
+    {"lower": 0,
+     "upper": "1", "name": "Liquid", "color": "#4292c4"}
+  ]
+}`,
+			line: 5,
+			msg:  "expected float64",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadSet(strings.NewReader(tc.input))
+
+			var fe *FileError
+			if !errors.As(err, &fe) {
+				t.Fatalf("expected *FileError, got %v", err)
+			}
+			if fe.Line != tc.line || !strings.Contains(fe.Error(), tc.msg) {
+				t.Errorf("got %q (line %d), want line %d containing %q", fe.Error(), fe.Line, tc.line, tc.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package biome
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Допуск при сравнении границ диапазонов, чтобы 0.1+0.2 не давало ложных разрывов.
+const boundsEpsilon = 1e-9
+
+// GapError — участок высот, не покрытый ни одним биомом.
+type GapError struct {
+	LowerBound, UpperBound float64
+}
+
+func (e *GapError) Error() string {
+	return fmt.Sprintf("biome: heights [%.5f, %.5f) are not covered by any biome", e.LowerBound, e.UpperBound)
+}
+
+// OverlapError — два биома с одинаковым приоритетом претендуют на один участок высот.
+type OverlapError struct {
+	LowerBound, UpperBound float64
+	First, Second          Data
+}
+
+func (e *OverlapError) Error() string {
+	return fmt.Sprintf("biome: %q and %q overlap on [%.5f, %.5f) with equal priority",
+		e.First.NameRU, e.Second.NameRU, e.LowerBound, e.UpperBound)
+}
+
+// RangeError — у биома некорректный диапазон (нижняя граница не меньше верхней).
+type RangeError struct {
+	Biome WorldBiome
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("biome: %q has empty range [%.5f, %.5f)",
+		e.Biome.Data.NameRU, e.Biome.LowerBound, e.Biome.UpperBound)
+}
+
+// Set — набор биомов, покрывающий диапазон высот [0, 1].
+// Пересечения разрешаются по приоритету (больший побеждает), а разрывы
+// допустимы только при заданном Fallback.
+type Set struct {
+	Biomes   []WorldBiome
+	Fallback *Data
+}
+
+func NewSet(biomes ...WorldBiome) *Set {
+	return &Set{
+		Biomes: append([]WorldBiome(nil), biomes...),
+	}
+}
+
+func (s *Set) Add(lowerBound, upperBound float64, data Data) *WorldBiome {
+	return s.AddWithPriority(lowerBound, upperBound, 0, data)
+}
+
+func (s *Set) AddWithPriority(lowerBound, upperBound float64, priority int, data Data) *WorldBiome {
+	b := NewWorldBiome(lowerBound, upperBound, data)
+	b.Priority = priority
+	s.Biomes = append(s.Biomes, *b)
+
+	return &s.Biomes[len(s.Biomes)-1]
+}
+
+func (s *Set) SetFallback(data Data) {
+	s.Fallback = &data
+}
+
+// Validate проверяет набор и возвращает все найденные проблемы сразу,
+// объединённые через errors.Join. Разрывы не считаются ошибкой, если задан Fallback.
+func (s *Set) Validate() error {
+	_, err := s.Resolve()
+	return err
+}
+
+// Resolve раскладывает биомы в упорядоченный список непересекающихся диапазонов.
+// На пересечениях остаётся биом с большим приоритетом; соседние куски одного
+// биома склеиваются обратно.
+func (s *Set) Resolve() ([]WorldBiome, error) {
+	var errs []error
+
+	if len(s.Biomes) == 0 {
+		if s.Fallback != nil {
+			return nil, nil
+		}
+		return nil, errors.New("biome: set is empty and has no fallback")
+	}
+
+	// Все границы делят [0, 1] на элементарные отрезки
+	points := []float64{0, 1}
+	for _, b := range s.Biomes {
+		if b.UpperBound-b.LowerBound <= boundsEpsilon {
+			errs = append(errs, &RangeError{Biome: b})
+			continue
+		}
+		points = append(points, b.LowerBound, b.UpperBound)
+	}
+	sort.Float64s(points)
+	points = uniqueBounds(points)
+
+	var resolved []WorldBiome
+	for i := 0; i+1 < len(points); i++ {
+		lo, hi := points[i], points[i+1]
+		mid := (lo + hi) / 2
+
+		winner := -1
+		for j, b := range s.Biomes {
+			if b.UpperBound-b.LowerBound <= boundsEpsilon || mid < b.LowerBound || mid >= b.UpperBound {
+				continue
+			}
+			if winner == -1 || b.Priority > s.Biomes[winner].Priority {
+				winner = j
+				continue
+			}
+			if b.Priority == s.Biomes[winner].Priority && b.Data != s.Biomes[winner].Data {
+				errs = append(errs, &OverlapError{
+					LowerBound: lo,
+					UpperBound: hi,
+					First:      s.Biomes[winner].Data,
+					Second:     b.Data,
+				})
+			}
+		}
+
+		if winner == -1 {
+			if s.Fallback == nil {
+				errs = appendGap(errs, lo, hi)
+			}
+			continue
+		}
+
+		w := s.Biomes[winner]
+		if n := len(resolved); n > 0 && resolved[n-1].Data == w.Data && resolved[n-1].Priority == w.Priority &&
+			math.Abs(resolved[n-1].UpperBound-lo) <= boundsEpsilon {
+			resolved[n-1].UpperBound = hi
+			continue
+		}
+		resolved = append(resolved, WorldBiome{LowerBound: lo, UpperBound: hi, Priority: w.Priority, Data: w.Data})
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return resolved, nil
+}
+
+// Pick возвращает биом для высоты так же, как генератор: по Lookup, а вне
+// диапазонов — Fallback.
+func (s *Set) Pick(height float64) (Data, bool) {
+	if b := Lookup(s.Biomes, height); b != nil {
+		return b.Data, true
+	}
+	if s.Fallback != nil {
+		return *s.Fallback, true
+	}
+
+	return Data{}, false
+}
+
+func uniqueBounds(points []float64) []float64 {
+	out := points[:1]
+	for _, p := range points[1:] {
+		if p-out[len(out)-1] > boundsEpsilon {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// appendGap склеивает соседние непокрытые отрезки в один GapError.
+func appendGap(errs []error, lo, hi float64) []error {
+	if n := len(errs); n > 0 {
+		var gap *GapError
+		if errors.As(errs[n-1], &gap) && math.Abs(gap.UpperBound-lo) <= boundsEpsilon {
+			gap.UpperBound = hi
+			return errs
+		}
+	}
+	return append(errs, &GapError{LowerBound: lo, UpperBound: hi})
+}
@@ -0,0 +1,90 @@
+package biome
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetResolveByPriority(t *testing.T) {
+	set := NewSet()
+	set.Add(0, 0.5, Data{Name: "Liquid", Color: "#0000ff"})
+	set.Add(0.5, 1, Data{Name: "Fields", Color: "#00ff00"})
+	set.AddWithPriority(0.4, 0.6, 1, Data{Name: "Coast", Color: "#ffff00"})
+
+	resolved, err := set.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	want := []struct {
+		lo, hi float64
+		name   string
+	}{
+		{0, 0.4, "Liquid"},
+		{0.4, 0.6, "Coast"},
+		{0.6, 1, "Fields"},
+	}
+	if len(resolved) != len(want) {
+		t.Fatalf("got %d ranges, want %d: %+v", len(resolved), len(want), resolved)
+	}
+	for i, w := range want {
+		r := resolved[i]
+		if r.LowerBound != w.lo || r.UpperBound != w.hi || r.Data.Name != w.name {
+			t.Errorf("range %d = [%v, %v) %s, want [%v, %v) %s", i, r.LowerBound, r.UpperBound, r.Data.Name, w.lo, w.hi, w.name)
+		}
+	}
+}
+
+func TestSetValidateReportsGapsAndOverlaps(t *testing.T) {
+	set := NewSet()
+	set.Add(0, 0.3, Data{NameRU: "Океан"})
+	set.Add(0.2, 0.5, Data{NameRU: "Побережье"})
+	set.Add(0.6, 1, Data{NameRU: "Горы"})
+
+	err := set.Validate()
+
+	var gap *GapError
+	if !errors.As(err, &gap) || gap.LowerBound != 0.5 || gap.UpperBound != 0.6 {
+		t.Errorf("expected gap [0.5, 0.6), got %v", err)
+	}
+	var overlap *OverlapError
+	if !errors.As(err, &overlap) || overlap.LowerBound != 0.2 || overlap.UpperBound != 0.3 {
+		t.Errorf("expected overlap [0.2, 0.3), got %v", err)
+	}
+}
+
+func TestSetFallbackCoversGaps(t *testing.T) {
+	set := NewSet()
+	set.Add(0, 0.5, Data{Name: "Liquid"})
+	set.SetFallback(Data{Name: "Void"})
+
+	if err := set.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if d, ok := set.Pick(0.75); !ok || d.Name != "Void" {
+		t.Errorf("Pick(0.75) = %v, %v; want fallback", d, ok)
+	}
+	if d, ok := set.Pick(0.25); !ok || d.Name != "Liquid" {
+		t.Errorf("Pick(0.25) = %v, %v; want Liquid", d, ok)
+	}
+}
+
+func TestLookupIncludesTopOfRange(t *testing.T) {
+	set := NewSet()
+	set.Add(0, 0.5, Data{Name: "Liquid"})
+	set.Add(0.5, 1, Data{Name: "Mounts"})
+	set.AddWithPriority(0.2, 0.3, 1, Data{Name: "Reef"})
+	set.SetFallback(Data{Name: "Void"})
+
+	for _, tc := range []struct {
+		height float64
+		want   string
+	}{{0, "Liquid"}, {0.25, "Reef"}, {0.5, "Mounts"}, {1, "Mounts"}} {
+		if b := Lookup(set.Biomes, tc.height); b == nil || b.Data.Name != tc.want {
+			t.Errorf("Lookup(%v) = %v, want %s", tc.height, b, tc.want)
+		}
+		if d, _ := set.Pick(tc.height); d.Name != tc.want {
+			t.Errorf("Pick(%v) = %s, want %s", tc.height, d.Name, tc.want)
+		}
+	}
+}
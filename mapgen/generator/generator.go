@@ -3,21 +3,29 @@ package generator
 import (
 	"log"
 	"tilemap-generator/mapgen/biome"
-	"tilemap-generator/mapgen/utils"
 	"tilemap-generator/mapgen/world"
 	"time"
 )
 
 type WorldGeneratorParams struct {
-	Seed      int
-	OffsetX   int64
-	OffsetY   int64
-	Frequency float64
+	Seed      int     `json:"seed"`
+	OffsetX   int64   `json:"offset_x"`
+	OffsetY   int64   `json:"offset_y"`
+	Frequency float64 `json:"frequency"`
+
+	Noise NoiseProfile `json:"noise"`
+
+	// Эскиз, задающий крупную форму карты; nil — только шум.
+	Sketch *Sketch `json:"sketch,omitempty"`
 }
 
 type WorldGenerator struct {
 	Config world.Config
 	Biomes []biome.WorldBiome
+
+	// Биом для высот, не попавших ни в один диапазон. Без него такие клетки
+	// остаются нулевым biome.Data.
+	Fallback *biome.Data
 }
 
 func NewGenerator(config world.Config, biomes []biome.WorldBiome) *WorldGenerator {
@@ -27,6 +35,20 @@ func NewGenerator(config world.Config, biomes []biome.WorldBiome) *WorldGenerato
 	}
 }
 
+// NewGeneratorFromSet проверяет набор биомов и строит генератор по уже
+// разрешённым (непересекающимся) диапазонам.
+func NewGeneratorFromSet(config world.Config, set *biome.Set) (*WorldGenerator, error) {
+	biomes, err := set.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	wg := NewGenerator(config, biomes)
+	wg.Fallback = set.Fallback
+
+	return wg, nil
+}
+
 func (wg *WorldGenerator) AddBiome(lowerBound, upperBound float64, data biome.Data) biome.WorldBiome {
 	b := biome.NewWorldBiome(lowerBound, upperBound, data)
 	wg.Biomes = append(wg.Biomes, *b)
@@ -42,45 +64,115 @@ func (wg *WorldGenerator) GetBiomes() []biome.WorldBiome {
 	return wg.Biomes
 }
 
+// Validate сообщает о разрывах и пересечениях в текущих диапазонах генератора.
+func (wg *WorldGenerator) Validate() error {
+	set := biome.NewSet(wg.Biomes...)
+	set.Fallback = wg.Fallback
+
+	return set.Validate()
+}
+
 func (wg *WorldGenerator) PeakBiome(height float64) *biome.WorldBiome {
-	for _, b := range wg.Biomes {
-		// Проверка, попадает ли высота в диапазон биома
-		if height >= b.LowerBound && height < b.UpperBound {
-			// Логируем, какой биом был выбран
-			log.Printf("Height %.5f is in range [%.5f, %.5f), selecting biome: %s\n", height, b.LowerBound, b.UpperBound, b.Data.NameRU)
-			return &b // Возвращаем ссылку на биом
-		}
+	b := wg.pick(height)
+	if b != nil {
+		// Логируем, какой биом был выбран
+		log.Printf("Height %.5f is in range [%.5f, %.5f), selecting biome: %s\n", height, b.LowerBound, b.UpperBound, b.Data.NameRU)
 	}
 
-	return nil // Если биом не найден
+	return b
 }
 
+// pick — PeakBiome без журнала: генерация вызывает его для каждой клетки,
+// и строка лога на клетку делала большие карты и тайлы сервера неподъёмными.
+func (wg *WorldGenerator) pick(height float64) *biome.WorldBiome {
+	return biome.Lookup(wg.Biomes, height)
+}
+
+// Generate строит мир размером Config. Эскиз в params должен пройти Sketch.Validate —
+// recipe проверяет его сам; неверный эскиз здесь пропускается с записью в лог.
 func (wg *WorldGenerator) Generate(params WorldGeneratorParams) *world.World {
 	currentSeed := params.Seed
 	if currentSeed == 0 {
 		currentSeed = int(time.Now().Unix())
 	}
 
-	matrix := make([][]biome.Data, wg.Config.Height)
+	heights := make([][]float64, wg.Config.Height)
 
 	// NOISE SETTINGS
-	var noise = utils.New[float64]()
-	noise.NoiseType(utils.OpenSimplex2S)
-	noise.Seed = currentSeed
-	noise.Frequency = params.Frequency
+	noise := newNoise(params.Noise, currentSeed, params.Frequency)
 
 	for y := int64(0); y < wg.Config.Height; y++ {
-		matrix[y] = make([]biome.Data, wg.Config.Width)
+		heights[y] = make([]float64, wg.Config.Width)
 		for x := int64(0); x < wg.Config.Width; x++ {
 			height := noise.Noise2D(int(x+params.OffsetX), int(y+params.OffsetY))
-			height = (height + 1) / 2
+			heights[y][x] = (height + 1) / 2
+		}
+	}
+
+	if err := applySketch(heights, params.Sketch, wg.Biomes); err != nil {
+		log.Printf("Sketch ignored: %v", err)
+	}
+
+	return wg.assemble(heights, currentSeed)
+}
+
+// GenerateChunk строит участок бесконечного мира width×height, начиная с клетки
+// origin (смещения из params добавляются). step > 1 берёт каждую step-ю клетку —
+// так строятся уменьшенные виды без генерации всех клеток. Config не используется,
+// эскиз не применяется: он привязан к размерам конечного мира. При step = 1
+// чанк совпадает с тем же участком Generate без эскиза.
+func (wg *WorldGenerator) GenerateChunk(params WorldGeneratorParams, origin world.Point, width, height, step int64) *world.World {
+	currentSeed := params.Seed
+	if currentSeed == 0 {
+		currentSeed = int(time.Now().Unix())
+	}
+	step = max(step, 1)
+
+	noise := newNoise(params.Noise, currentSeed, params.Frequency)
+	heights := make([][]float64, height)
+	for y := int64(0); y < height; y++ {
+		heights[y] = make([]float64, width)
+		wy := origin.Y + y*step + params.OffsetY
+		for x := int64(0); x < width; x++ {
+			wx := origin.X + x*step + params.OffsetX
+			heights[y][x] = (noise.Noise2D(int(wx), int(wy)) + 1) / 2
+		}
+	}
+
+	return wg.assemble(heights, currentSeed)
+}
+
+// GenerateFromHeights строит мир по готовому полю высот в [0, 1] вместо шума,
+// например по импортированной карте высот. Поле растягивается до размеров
+// из Config, если они отличаются. Непрямоугольное поле — ошибка.
+func (wg *WorldGenerator) GenerateFromHeights(heights [][]float64, params WorldGeneratorParams) (*world.World, error) {
+	currentSeed := params.Seed
+	if currentSeed == 0 {
+		currentSeed = int(time.Now().Unix())
+	}
+
+	resampled, err := ResampleHeights(heights, wg.Config.Width, wg.Config.Height)
+	if err != nil {
+		return nil, err
+	}
+	return wg.assemble(resampled, currentSeed), nil
+}
+
+// assemble раскладывает биомы по полю высот. Сюда сходятся все пути генерации.
+func (wg *WorldGenerator) assemble(heights [][]float64, seed int) *world.World {
+	matrix := make([][]biome.Data, len(heights))
 
-			b := wg.PeakBiome(height)
+	for y, row := range heights {
+		matrix[y] = make([]biome.Data, len(row))
+		for x, height := range row {
+			b := wg.pick(height)
 			if b != nil {
 				matrix[y][x] = b.Data
+			} else if wg.Fallback != nil {
+				matrix[y][x] = *wg.Fallback
 			}
 		}
 	}
 
-	return world.NewWorld(matrix, currentSeed)
+	return world.NewWorldWithHeights(matrix, heights, seed)
 }
@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+func TestGenerateChunkMatchesGenerate(t *testing.T) {
+	wg := NewGenerator(world.Config{Width: 32, Height: 24}, nil)
+	wg.AddBiome(0, 0.5, biome.Data{Name: "Liquid", Color: "#0000ff"})
+	wg.AddBiome(0.5, 1, biome.Data{Name: "Fields", Color: "#00ff00"})
+	params := WorldGeneratorParams{Seed: 3, OffsetX: 5, OffsetY: -7, Frequency: 0.05}
+
+	full := wg.Generate(params)
+
+	chunk := wg.GenerateChunk(params, world.Point{X: 8, Y: 4}, 10, 6, 1)
+	for y := int64(0); y < chunk.Height; y++ {
+		for x := int64(0); x < chunk.Width; x++ {
+			if got, want := chunk.Heights[y][x], full.Heights[y+4][x+8]; got != want {
+				t.Fatalf("chunk (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	// С шагом берётся каждая step-я клетка того же мира
+	coarse := wg.GenerateChunk(params, world.Point{X: 2, Y: 0}, 5, 4, 6)
+	if got, want := coarse.Heights[3][4], full.Heights[18][26]; got != want {
+		t.Errorf("coarse chunk sample = %v, want %v", got, want)
+	}
+	if got, want := coarse.Matrix[3][4], full.Matrix[18][26]; got != want {
+		t.Errorf("coarse chunk biome = %v, want %v", got, want)
+	}
+}
+
+func TestGeneratorPicksLikeSet(t *testing.T) {
+	set := biome.NewSet()
+	set.Add(0, 0.5, biome.Data{Name: "Liquid", Color: "#0000ff"})
+	set.Add(0.5, 1, biome.Data{Name: "Mounts", Color: "#808080"})
+	set.SetFallback(biome.Data{Name: "Void", Color: "#000000"})
+	wg, err := NewGeneratorFromSet(world.Config{Width: 4, Height: 1}, set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	heights := []float64{0, 0.5, 0.999, 1}
+	w, err := wg.GenerateFromHeights([][]float64{heights}, WorldGeneratorParams{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x, h := range heights {
+		if want, _ := set.Pick(h); w.Matrix[0][x] != want {
+			t.Errorf("height %v: generator picked %s, set picks %s", h, w.Matrix[0][x].Name, want.Name)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ResampleHeights билинейно растягивает или сжимает поле высот до width×height.
+// Если размеры уже совпадают, возвращается исходное поле. Поле должно быть
+// непустым и прямоугольным.
+func ResampleHeights(src [][]float64, width, height int64) ([][]float64, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("generator: resample size must be positive, got %dx%d", width, height)
+	}
+	if len(src) == 0 || len(src[0]) == 0 {
+		return nil, errors.New("generator: heights are empty")
+	}
+	for y, row := range src {
+		if len(row) != len(src[0]) {
+			return nil, fmt.Errorf("generator: heights row %d has %d cells, want %d", y, len(row), len(src[0]))
+		}
+	}
+
+	srcH, srcW := int64(len(src)), int64(len(src[0]))
+	if srcW == width && srcH == height {
+		return src, nil
+	}
+
+	dst := make([][]float64, height)
+	for y := int64(0); y < height; y++ {
+		dst[y] = make([]float64, width)
+		// Выравниваем центры клеток, чтобы края не смещались при масштабировании
+		fy := (float64(y)+0.5)*float64(srcH)/float64(height) - 0.5
+		y0 := int64(math.Floor(fy))
+		ty := fy - float64(y0)
+
+		for x := int64(0); x < width; x++ {
+			fx := (float64(x)+0.5)*float64(srcW)/float64(width) - 0.5
+			x0 := int64(math.Floor(fx))
+			tx := fx - float64(x0)
+
+			at := func(x, y int64) float64 {
+				x = min(max(x, 0), srcW-1)
+				y = min(max(y, 0), srcH-1)
+				return src[y][x]
+			}
+
+			top := at(x0, y0)*(1-tx) + at(x0+1, y0)*tx
+			bottom := at(x0, y0+1)*(1-tx) + at(x0+1, y0+1)*tx
+			dst[y][x] = top*(1-ty) + bottom*ty
+		}
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+func TestResampleHeights(t *testing.T) {
+	src := [][]float64{
+		{0, 0.2, 0.4, 0.6},
+		{0.1, 0.3, 0.5, 0.7},
+		{0.2, 0.4, 0.6, 0.8},
+		{0.3, 0.5, 0.7, 0.9},
+	}
+
+	same, err := ResampleHeights(src, 4, 4)
+	if err != nil || !reflect.DeepEqual(same, src) {
+		t.Fatalf("identity resample = %v, %v", same, err)
+	}
+
+	// Уменьшение вдвое — среднее каждого блока 2×2
+	down, err := ResampleHeights(src, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]float64{{0.15, 0.55}, {0.35, 0.75}}
+	for y := range want {
+		for x := range want[y] {
+			if math.Abs(down[y][x]-want[y][x]) > 1e-9 {
+				t.Errorf("downscaled (%d, %d) = %v, want %v", x, y, down[y][x], want[y][x])
+			}
+		}
+	}
+
+	// При увеличении углы сохраняются, а значения растут вдоль склона
+	up, err := ResampleHeights([][]float64{{0, 1}, {1, 1}}, 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(up) != 4 || len(up[0]) != 4 {
+		t.Fatalf("upscaled size %dx%d, want 4x4", len(up[0]), len(up))
+	}
+	if up[0][0] != 0 || up[3][3] != 1 {
+		t.Errorf("upscaled corners = %v, %v, want 0, 1", up[0][0], up[3][3])
+	}
+	for x := 1; x < 4; x++ {
+		if up[0][x] < up[0][x-1] {
+			t.Errorf("upscaled row 0 is not monotonic: %v", up[0])
+		}
+	}
+}
+
+func TestResampleHeightsRejectsJaggedInput(t *testing.T) {
+	for name, src := range map[string][][]float64{
+		"jagged": {{0, 1}, {0}},
+		"empty":  {},
+		"no row": {{}},
+	} {
+		if _, err := ResampleHeights(src, 4, 4); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGenerateFromHeightsAssignsBiomes(t *testing.T) {
+	wg := NewGenerator(world.Config{Width: 3, Height: 2}, nil)
+	wg.AddBiome(0, 0.5, biome.Data{Name: "Liquid", Color: "#0000ff"})
+	wg.AddBiome(0.5, 1, biome.Data{Name: "Fields", Color: "#00ff00"})
+
+	w, err := wg.GenerateFromHeights([][]float64{{0.1, 0.6, 0.9}, {0.4, 0.5, 0.2}}, WorldGeneratorParams{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"Liquid", "Fields", "Fields"}, {"Liquid", "Fields", "Liquid"}}
+	for y, row := range want {
+		for x, name := range row {
+			if got := w.Matrix[y][x].Name; got != name {
+				t.Errorf("cell (%d, %d) = %s, want %s", x, y, got, name)
+			}
+		}
+	}
+
+	if _, err := wg.GenerateFromHeights([][]float64{{0.1, 0.2}, {0.3}}, WorldGeneratorParams{Seed: 1}); err == nil {
+		t.Error("jagged heights accepted")
+	}
+}
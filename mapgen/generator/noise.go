@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"fmt"
+	"tilemap-generator/mapgen/utils"
+)
+
+// NoiseProfile описывает алгоритм шума для карты высот.
+// Нулевое значение соответствует исходному поведению: OpenSimplex2S без фрактала.
+type NoiseProfile struct {
+	Type             string  `json:"type,omitempty"`
+	Fractal          string  `json:"fractal,omitempty"`
+	Octaves          int     `json:"octaves,omitempty"`
+	Lacunarity       float64 `json:"lacunarity,omitempty"`
+	Gain             float64 `json:"gain,omitempty"`
+	WeightedStrength float64 `json:"weighted_strength,omitempty"`
+}
+
+var noiseTypes = map[string]utils.NoiseType{
+	"OpenSimplex2":  utils.OpenSimplex2,
+	"OpenSimplex2S": utils.OpenSimplex2S,
+	"Cellular":      utils.Cellular,
+	"Perlin":        utils.Perlin,
+	"ValueCubic":    utils.ValueCubic,
+	"Value":         utils.Value,
+}
+
+var fractalTypes = map[string]utils.FractalType{
+	"None":     utils.FractalNone,
+	"FBm":      utils.FractalFBm,
+	"Ridged":   utils.FractalRidged,
+	"PingPong": utils.FractalPingPong,
+}
+
+func (p NoiseProfile) Validate() error {
+	if _, ok := noiseTypes[p.Type]; p.Type != "" && !ok {
+		return fmt.Errorf("generator: unknown noise type %q", p.Type)
+	}
+	if _, ok := fractalTypes[p.Fractal]; p.Fractal != "" && !ok {
+		return fmt.Errorf("generator: unknown fractal type %q", p.Fractal)
+	}
+	if p.Octaves < 0 {
+		return fmt.Errorf("generator: octaves must not be negative, got %d", p.Octaves)
+	}
+	return nil
+}
+
+// newNoise собирает состояние FastNoiseLite по профилю. Незаданные поля
+// остаются значениями по умолчанию из utils.New.
+func newNoise(p NoiseProfile, seed int, frequency float64) *utils.State[float64] {
+	noise := utils.New[float64]()
+	noise.Seed = seed
+	noise.Frequency = frequency
+
+	noiseType, ok := noiseTypes[p.Type]
+	if !ok {
+		noiseType = utils.OpenSimplex2S
+	}
+	noise.NoiseType(noiseType)
+
+	if fractal, ok := fractalTypes[p.Fractal]; ok {
+		noise.FractalType(fractal)
+	}
+	if p.Octaves > 0 {
+		noise.Octaves = p.Octaves
+	}
+	if p.Lacunarity != 0 {
+		noise.Lacunarity = p.Lacunarity
+	}
+	if p.Gain != 0 {
+		noise.Gain = p.Gain
+	}
+	noise.WeightedStrength = p.WeightedStrength
+
+	return noise
+}
@@ -0,0 +1,152 @@
+package generator
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"tilemap-generator/mapgen/biome"
+)
+
+// SketchColor задаёт, какой диапазон высот означает цвет на эскизе.
+// Если указан Biome (NameRU или Name), диапазон берётся из биомов генератора.
+type SketchColor struct {
+	Color      string  `json:"color"`
+	LowerBound float64 `json:"lower,omitempty"`
+	UpperBound float64 `json:"upper,omitempty"`
+	Biome      string  `json:"biome,omitempty"`
+}
+
+// Sketch — нарисованная от руки маска (суша, вода, горы), задающая крупную форму карты.
+// Эскиз растягивается на весь мир, а шум добавляет детали внутри диапазонов.
+type Sketch struct {
+	// Путь к картинке; сам генератор файлы не читает, это делает recipe.
+	Path   string        `json:"path,omitempty"`
+	Image  image.Image   `json:"-"`
+	Colors []SketchColor `json:"colors"`
+
+	// 0 — эскиз не влияет на карту, 1 — высота всегда внутри диапазона цвета.
+	Strength float64 `json:"strength"`
+}
+
+// Validate проверяет цвета эскиза относительно биомов генератора и саму картинку,
+// если она уже загружена.
+func (s *Sketch) Validate(biomes []biome.WorldBiome) error {
+	if s.Image == nil && s.Path == "" {
+		return fmt.Errorf("generator: sketch has neither a path nor an image")
+	}
+	if s.Image != nil && s.Image.Bounds().Empty() {
+		return fmt.Errorf("generator: sketch image is empty")
+	}
+	if math.IsNaN(s.Strength) || s.Strength < 0 {
+		return fmt.Errorf("generator: sketch strength must be non-negative, got %v", s.Strength)
+	}
+	_, err := s.ranges(biomes)
+	return err
+}
+
+type sketchRange struct {
+	rgb    [3]float64
+	lo, hi float64
+}
+
+// ranges сопоставляет цвета эскиза диапазонам высот.
+func (s *Sketch) ranges(biomes []biome.WorldBiome) ([]sketchRange, error) {
+	out := make([]sketchRange, 0, len(s.Colors))
+	for _, c := range s.Colors {
+		var r, g, b uint8
+		if _, err := fmt.Sscanf(c.Color, "#%02x%02x%02x", &r, &g, &b); err != nil {
+			return nil, fmt.Errorf("generator: sketch color %q: %w", c.Color, err)
+		}
+
+		lo, hi := c.LowerBound, c.UpperBound
+		if c.Biome != "" {
+			found := false
+			for _, wb := range biomes {
+				if wb.Data.NameRU != c.Biome && wb.Data.Name != c.Biome {
+					continue
+				}
+				// Для группы биомов (по Name) берём объединение их диапазонов
+				if !found {
+					lo, hi = wb.LowerBound, wb.UpperBound
+				} else {
+					lo, hi = math.Min(lo, wb.LowerBound), math.Max(hi, wb.UpperBound)
+				}
+				found = true
+			}
+			if !found {
+				return nil, fmt.Errorf("generator: sketch color %q refers to unknown biome %q", c.Color, c.Biome)
+			}
+		}
+		if lo >= hi {
+			return nil, fmt.Errorf("generator: sketch color %q has empty range [%v, %v)", c.Color, lo, hi)
+		}
+
+		out = append(out, sketchRange{rgb: [3]float64{float64(r), float64(g), float64(b)}, lo: lo, hi: hi})
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("generator: sketch has no colors")
+	}
+	return out, nil
+}
+
+// fields переводит эскиз в поля нижних и верхних границ размером с мир.
+// Каждый пиксель относится к ближайшему по RGB цвету, а границы между
+// пикселями сглаживаются билинейно, чтобы не было ступенек.
+func (s *Sketch) fields(biomes []biome.WorldBiome, width, height int64) ([][]float64, [][]float64, error) {
+	ranges, err := s.ranges(biomes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bounds := s.Image.Bounds()
+	lo := make([][]float64, bounds.Dy())
+	hi := make([][]float64, bounds.Dy())
+	for y := range lo {
+		lo[y] = make([]float64, bounds.Dx())
+		hi[y] = make([]float64, bounds.Dx())
+		for x := range lo[y] {
+			c := color.NRGBAModel.Convert(s.Image.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			best, bestDist := 0, math.Inf(1)
+			for i, r := range ranges {
+				dr, dg, db := float64(c.R)-r.rgb[0], float64(c.G)-r.rgb[1], float64(c.B)-r.rgb[2]
+				if d := dr*dr + dg*dg + db*db; d < bestDist {
+					best, bestDist = i, d
+				}
+			}
+			lo[y][x], hi[y][x] = ranges[best].lo, ranges[best].hi
+		}
+	}
+
+	if lo, err = ResampleHeights(lo, width, height); err != nil {
+		return nil, nil, err
+	}
+	if hi, err = ResampleHeights(hi, width, height); err != nil {
+		return nil, nil, err
+	}
+	return lo, hi, nil
+}
+
+// applySketch смешивает высоты шума с эскизом: шум масштабируется внутрь
+// диапазона цвета и смешивается с исходным значением по Strength.
+func applySketch(heights [][]float64, s *Sketch, biomes []biome.WorldBiome) error {
+	if s == nil || s.Image == nil || s.Strength <= 0 || len(heights) == 0 {
+		return nil
+	}
+
+	lo, hi, err := s.fields(biomes, int64(len(heights[0])), int64(len(heights)))
+	if err != nil {
+		return err
+	}
+
+	strength := math.Min(s.Strength, 1)
+	for y, row := range heights {
+		for x, h := range row {
+			target := lo[y][x] + h*(hi[y][x]-lo[y][x])
+			row[x] = h + (target-h)*strength
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/world"
+)
+
+func TestSketchDrivesMacroShape(t *testing.T) {
+	wg := NewGenerator(world.Config{Width: 40, Height: 10}, nil)
+	wg.AddBiome(0, 0.5, biome.Data{Name: "Liquid", Color: "#0000ff"})
+	wg.AddBiome(0.5, 1, biome.Data{Name: "Fields", Color: "#00ff00"})
+
+	mask := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	mask.Set(0, 0, color.RGBA{B: 250, A: 255})
+	mask.Set(1, 0, color.RGBA{G: 240, A: 255})
+
+	w := wg.Generate(WorldGeneratorParams{
+		Seed:      1,
+		Frequency: 0.05,
+		Sketch: &Sketch{
+			Image: mask,
+			Colors: []SketchColor{
+				{Color: "#0000ff", Biome: "Liquid"},
+				{Color: "#00ff00", Biome: "Fields"},
+			},
+			Strength: 1,
+		},
+	})
+
+	// Вдали от границы эскиза биом определяется только цветом маски
+	for y := int64(0); y < w.Height; y++ {
+		if got := w.GetAt(world.Point{X: 2, Y: y}).Name; got != "Liquid" {
+			t.Fatalf("cell (2, %d) = %s, want Liquid", y, got)
+		}
+		if got := w.GetAt(world.Point{X: 37, Y: y}).Name; got != "Fields" {
+			t.Fatalf("cell (37, %d) = %s, want Fields", y, got)
+		}
+	}
+}
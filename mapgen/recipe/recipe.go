@@ -0,0 +1,301 @@
+package recipe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	mapimage "tilemap-generator/image"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/generator"
+	"tilemap-generator/mapgen/world"
+)
+
+// Version — версия формата рецепта. Рецепты более новой версии не читаются,
+// чтобы старый генератор молча не построил другую карту.
+const Version = 1
+
+// Recipe — всё, что нужно для повторной генерации того же мира:
+// размеры и настройки мира, параметры генерации (включая шум) и биомы.
+type Recipe struct {
+	Version int
+	Config  world.Config
+	Params  generator.WorldGeneratorParams
+	Biomes  *biome.Set
+	// Карта высот (PNG, .r32, .r16) вместо шума: биомы раскладываются по готовому
+	// рельефу, растянутому до Config. Относительный путь — от файла рецепта.
+	Heightmap string
+
+	// Каталог файла рецепта: относительные пути (эскиз, карта высот) считаются от него.
+	dir string
+}
+
+type recipeFile struct {
+	Version   int                            `json:"version"`
+	Config    world.Config                   `json:"config"`
+	Params    generator.WorldGeneratorParams `json:"params"`
+	Biomes    json.RawMessage                `json:"biomes"`
+	Heightmap string                         `json:"heightmap,omitempty"`
+}
+
+func New(config world.Config, params generator.WorldGeneratorParams, biomes *biome.Set) *Recipe {
+	return &Recipe{
+		Version: Version,
+		Config:  config,
+		Params:  params,
+		Biomes:  biomes,
+	}
+}
+
+func (r *Recipe) Validate() error {
+	if r.Version < 1 {
+		return errors.New("recipe: missing version")
+	}
+	if r.Version > Version {
+		return fmt.Errorf("recipe: version %d is newer than supported version %d", r.Version, Version)
+	}
+	if r.Config.Width <= 0 || r.Config.Height <= 0 {
+		return fmt.Errorf("recipe: world size must be positive, got %dx%d", r.Config.Width, r.Config.Height)
+	}
+	if r.Biomes == nil {
+		return errors.New("recipe: biomes are missing")
+	}
+	if err := r.Params.Noise.Validate(); err != nil {
+		return fmt.Errorf("recipe: %w", err)
+	}
+	if err := r.Biomes.Validate(); err != nil {
+		return err
+	}
+	if r.Heightmap != "" && r.Params.Sketch != nil {
+		return errors.New("recipe: a sketch cannot be combined with a heightmap")
+	}
+	if r.Params.Sketch != nil {
+		biomes, _ := r.Biomes.Resolve()
+		if err := r.Params.Sketch.Validate(biomes); err != nil {
+			return fmt.Errorf("recipe: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Recipe) Generator() (*generator.WorldGenerator, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	return generator.NewGeneratorFromSet(r.Config, r.Biomes)
+}
+
+// Generate строит мир по рецепту. Если сид не задан, он выбирается по времени;
+// рецепт не меняется, выбранный сид — в World.Seed.
+func (r *Recipe) Generate() (*world.World, error) {
+	if err := r.Prepare(); err != nil {
+		return nil, err
+	}
+	g, err := generator.NewGeneratorFromSet(r.Config, r.Biomes)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Heightmap != "" {
+		heights, err := mapimage.LoadHeightmap(r.path(r.Heightmap))
+		if err != nil {
+			return nil, fmt.Errorf("recipe: heightmap: %w", err)
+		}
+		w, err := g.GenerateFromHeights(heights, r.Params)
+		if err != nil {
+			return nil, fmt.Errorf("recipe: heightmap %s: %w", r.Heightmap, err)
+		}
+		return w, nil
+	}
+
+	return g.Generate(r.Params), nil
+}
+
+// path переводит путь из рецепта в путь от текущего каталога.
+func (r *Recipe) path(p string) string {
+	if !filepath.IsAbs(p) && r.dir != "" {
+		return filepath.Join(r.dir, p)
+	}
+	return p
+}
+
+// Prepare загружает эскиз и проверяет рецепт вместе с картинкой эскиза. После этого
+// копии рецепта с разными сидами можно генерировать параллельно: эскиз они только читают.
+func (r *Recipe) Prepare() error {
+	if err := r.loadSketch(); err != nil {
+		return err
+	}
+	return r.Validate()
+}
+
+func (r *Recipe) loadSketch() error {
+	sketch := r.Params.Sketch
+	if sketch == nil || sketch.Image != nil || sketch.Path == "" {
+		return nil
+	}
+
+	path := r.path(sketch.Path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("recipe: sketch: %w", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return fmt.Errorf("recipe: sketch %s: %w", path, err)
+	}
+	sketch.Image = img
+
+	return nil
+}
+
+func Load(path string) (*Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := parse(data)
+	if err != nil {
+		var fe *biome.FileError
+		if errors.As(err, &fe) {
+			fe.Path = path
+		}
+		return nil, err
+	}
+	r.dir = filepath.Dir(path)
+
+	return r, nil
+}
+
+func Read(reader io.Reader) (*Recipe, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(data)
+}
+
+// Save пишет рецепт в файл. Относительные пути эскиза и карты высот
+// пересчитываются от нового каталога, чтобы рецепт можно было сохранить в другом месте.
+func Save(path string, r *Recipe) error {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	saved := *r
+	saved.Heightmap = r.rebase(r.Heightmap, dir)
+	if r.Params.Sketch != nil {
+		sketch := *r.Params.Sketch
+		sketch.Path = r.rebase(sketch.Path, dir)
+		saved.Params.Sketch = &sketch
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return Write(file, &saved)
+}
+
+// rebase переводит путь из рецепта в путь от каталога dir; если не выходит
+// (другой диск), остаётся абсолютный путь.
+func (r *Recipe) rebase(p, dir string) string {
+	if p == "" {
+		return p
+	}
+	abs, err := filepath.Abs(r.path(p))
+	if err != nil {
+		return p
+	}
+	if rel, err := filepath.Rel(dir, abs); err == nil {
+		return rel
+	}
+	return abs
+}
+
+func Write(w io.Writer, r *Recipe) error {
+	if r.Biomes == nil {
+		return errors.New("recipe: biomes are missing")
+	}
+	// Картинка в файл рецепта не попадает: без пути эскиз потерялся бы молча
+	if r.Params.Sketch != nil && r.Params.Sketch.Path == "" {
+		return errors.New("recipe: sketch without a path cannot be saved")
+	}
+
+	var biomes bytes.Buffer
+	if err := biome.WriteSet(&biomes, r.Biomes); err != nil {
+		return err
+	}
+
+	version := r.Version
+	if version == 0 {
+		version = Version
+	}
+
+	data, err := json.MarshalIndent(recipeFile{
+		Version:   version,
+		Config:    r.Config,
+		Params:    r.Params,
+		Biomes:    biomes.Bytes(),
+		Heightmap: r.Heightmap,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func parse(data []byte) (*Recipe, error) {
+	var file recipeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("recipe: %w", err)
+	}
+
+	r := &Recipe{
+		Version:   file.Version,
+		Config:    file.Config,
+		Params:    file.Params,
+		Heightmap: file.Heightmap,
+	}
+	// Версию проверяем до биомов: у будущих версий их схема может отличаться
+	if r.Version > Version || r.Version < 1 {
+		return nil, r.Validate()
+	}
+
+	if len(file.Biomes) == 0 {
+		return nil, errors.New("recipe: biomes are missing")
+	}
+	biomes, err := biome.ReadSet(bytes.NewReader(file.Biomes))
+	if err != nil {
+		// Номера строк из biome относятся к фрагменту — сдвигаем их к началу рецепта
+		var fe *biome.FileError
+		if errors.As(err, &fe) && fe.Line > 0 {
+			if idx := bytes.Index(data, file.Biomes); idx >= 0 {
+				fe.Line += bytes.Count(data[:idx], []byte("\n"))
+			}
+		}
+		return nil, err
+	}
+	r.Biomes = biomes
+
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,126 @@
+package recipe
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/generator"
+	"tilemap-generator/mapgen/world"
+)
+
+func TestRecipeRegeneratesIdenticalWorld(t *testing.T) {
+	set := biome.NewSet()
+	set.Add(0, 0.4, biome.Data{Name: "Liquid", NameRU: "Океан", Color: "#4292c4"})
+	set.Add(0.4, 1, biome.Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"})
+
+	r := New(world.Config{Width: 32, Height: 24}, generator.WorldGeneratorParams{
+		OffsetX:   7,
+		Frequency: 0.05,
+		Noise:     generator.NoiseProfile{Type: "Perlin", Fractal: "FBm", Octaves: 4},
+	}, set)
+
+	original, err := r.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if r.Params.Seed != 0 {
+		t.Fatalf("Generate changed the recipe seed to %d", r.Params.Seed)
+	}
+	r.Params.Seed = original.Seed
+
+	var buf bytes.Buffer
+	if err := Write(&buf, r); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	loaded, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	regenerated, err := loaded.Generate()
+	if err != nil {
+		t.Fatalf("Generate from loaded recipe: %v", err)
+	}
+	if !reflect.DeepEqual(original, regenerated) {
+		t.Fatal("regenerated world differs from the original")
+	}
+}
+
+func TestRecipeRejectsNewerVersion(t *testing.T) {
+	_, err := Read(strings.NewReader(`{"version": 99, "config": {"width": 1, "height": 1}, "biomes": {"whatever": true}}`))
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("expected version error, got %v", err)
+	}
+}
+
+func TestRecipeRejectsInvalidSketch(t *testing.T) {
+	set := biome.NewSet()
+	set.Add(0, 0.4, biome.Data{Name: "Liquid", NameRU: "Океан", Color: "#4292c4"})
+	set.Add(0.4, 1, biome.Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"})
+	mask := image.NewRGBA(image.Rect(0, 0, 2, 2))
+
+	for name, sketch := range map[string]*generator.Sketch{
+		"unknown biome": {Image: mask, Colors: []generator.SketchColor{{Color: "#0000ff", Biome: "Lava"}}, Strength: 1},
+		"bad color":     {Image: mask, Colors: []generator.SketchColor{{Color: "blue", Biome: "Liquid"}}, Strength: 1},
+		"no image":      {Colors: []generator.SketchColor{{Color: "#0000ff", Biome: "Liquid"}}, Strength: 1},
+		"empty image":   {Image: image.NewRGBA(image.Rectangle{}), Colors: []generator.SketchColor{{Color: "#0000ff", Biome: "Liquid"}}, Strength: 1},
+	} {
+		r := New(world.Config{Width: 8, Height: 8}, generator.WorldGeneratorParams{Seed: 1, Frequency: 0.05, Sketch: sketch}, set)
+		if _, err := r.Generate(); err == nil {
+			t.Errorf("%s: Generate accepted the sketch", name)
+		}
+	}
+}
+
+func TestWriteRejectsSketchWithoutPath(t *testing.T) {
+	set := biome.NewSet()
+	set.Add(0, 1, biome.Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"})
+	r := New(world.Config{Width: 8, Height: 8}, generator.WorldGeneratorParams{Seed: 1, Sketch: &generator.Sketch{
+		Image:    image.NewRGBA(image.Rect(0, 0, 2, 2)),
+		Colors:   []generator.SketchColor{{Color: "#00ff00", Biome: "Fields"}},
+		Strength: 1,
+	}}, set)
+
+	if err := Write(&bytes.Buffer{}, r); err == nil || !strings.Contains(err.Error(), "path") {
+		t.Fatalf("expected sketch path error, got %v", err)
+	}
+}
+
+func TestWriteRejectsMissingBiomes(t *testing.T) {
+	r := New(world.Config{Width: 8, Height: 8}, generator.WorldGeneratorParams{Seed: 1}, nil)
+	if err := Write(&bytes.Buffer{}, r); err == nil || !strings.Contains(err.Error(), "biomes") {
+		t.Fatalf("expected missing biomes error, got %v", err)
+	}
+}
+
+func TestSaveRebasesRelativePaths(t *testing.T) {
+	dir := t.TempDir()
+	set := biome.NewSet()
+	set.Add(0, 1, biome.Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"})
+	r := New(world.Config{Width: 8, Height: 8}, generator.WorldGeneratorParams{Seed: 1}, set)
+	r.Heightmap = "terrain.png"
+	r.dir = filepath.Join(dir, "a")
+
+	path := filepath.Join(dir, "b", "copy.recipe.json")
+	if err := os.Mkdir(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, r); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := filepath.Join("..", "a", "terrain.png"); loaded.Heightmap != want {
+		t.Errorf("heightmap %q, want %q", loaded.Heightmap, want)
+	}
+	if r.Heightmap != "terrain.png" {
+		t.Errorf("Save changed the recipe heightmap to %q", r.Heightmap)
+	}
+}
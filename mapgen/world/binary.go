@@ -0,0 +1,315 @@
+package world
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"tilemap-generator/mapgen/biome"
+)
+
+// Бинарный формат мира (все числа little-endian):
+//
+//	magic    [4]byte  "TMAP"
+//	version  uint16
+//	width    uint32
+//	height   uint32
+//	seed     int64
+//	palette  uint16 count, затем для каждого биома три строки (uint16 длина + UTF-8): Name, NameRU, Color
+//	layers   uint8 count, затем для каждого слоя:
+//	         kind uint8, raw size uint32, crc32 несжатых данных uint32,
+//	         compressed size uint32, данные zlib
+//
+// Слой биомов хранит uint16 индекс палитры на клетку, слои высот и расстояний —
+// float64 на клетку. Все идут построчно, как Matrix; незнакомые виды слоёв
+// при чтении пропускаются.
+
+const (
+	BinaryVersion = 1
+
+	layerBiomes   byte = 1
+	layerHeights  byte = 2
+	layerDistance byte = 3
+
+	// Ограничение на размер, чтобы испорченный заголовок не заставил выделить гигабайты памяти.
+	maxBinaryCells = 1 << 28
+)
+
+var binaryMagic = [4]byte{'T', 'M', 'A', 'P'}
+
+var (
+	ErrBadMagic           = errors.New("world: not a world file (bad magic)")
+	ErrUnsupportedVersion = errors.New("world: unsupported world file version")
+	ErrCorrupt            = errors.New("world: corrupt world file")
+)
+
+func (w *World) Save(out io.Writer) error {
+	palette := w.Palette()
+	if len(palette) > math.MaxUint16 {
+		return fmt.Errorf("world: palette has %d biomes, at most %d fit the format", len(palette), math.MaxUint16)
+	}
+	index := make(map[biome.Data]uint16, len(palette))
+	for i, b := range palette {
+		index[b] = uint16(i)
+	}
+
+	bw := bufio.NewWriter(out)
+	le := binary.LittleEndian
+
+	bw.Write(binaryMagic[:])
+	binary.Write(bw, le, uint16(BinaryVersion))
+	binary.Write(bw, le, uint32(w.Width))
+	binary.Write(bw, le, uint32(w.Height))
+	binary.Write(bw, le, int64(w.Seed))
+
+	binary.Write(bw, le, uint16(len(palette)))
+	for _, b := range palette {
+		for _, s := range []string{b.Name, b.NameRU, b.Color} {
+			if len(s) > math.MaxUint16 {
+				return fmt.Errorf("world: biome string %q is too long", s[:32])
+			}
+			binary.Write(bw, le, uint16(len(s)))
+			bw.WriteString(s)
+		}
+	}
+
+	cells := int(w.Width * w.Height)
+	biomes := make([]byte, 0, cells*2)
+	w.Each(func(_ Point, b biome.Data) bool {
+		biomes = le.AppendUint16(biomes, index[b])
+		return true
+	})
+
+	layers := [][]byte{biomes}
+	kinds := []byte{layerBiomes}
+	if w.HasHeights() {
+		layers = append(layers, encodeFloats(w.Heights, cells))
+		kinds = append(kinds, layerHeights)
+	}
+	if w.HasDistance() {
+		layers = append(layers, encodeFloats(w.Distance, cells))
+		kinds = append(kinds, layerDistance)
+	}
+
+	bw.WriteByte(byte(len(layers)))
+	for i, raw := range layers {
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(raw); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		bw.WriteByte(kinds[i])
+		binary.Write(bw, le, uint32(len(raw)))
+		binary.Write(bw, le, crc32.ChecksumIEEE(raw))
+		binary.Write(bw, le, uint32(compressed.Len()))
+		bw.Write(compressed.Bytes())
+	}
+
+	return bw.Flush()
+}
+
+// Load заменяет содержимое мира данными из потока. При ошибке мир не меняется.
+func (w *World) Load(in io.Reader) error {
+	r := bufio.NewReader(in)
+	le := binary.LittleEndian
+
+	var header struct {
+		Magic   [4]byte
+		Version uint16
+		Width   uint32
+		Height  uint32
+		Seed    int64
+	}
+	if err := binary.Read(r, le, &header); err != nil {
+		return truncated("header", err)
+	}
+	if header.Magic != binaryMagic {
+		return ErrBadMagic
+	}
+	if header.Version != BinaryVersion {
+		return fmt.Errorf("%w: %d (expected %d)", ErrUnsupportedVersion, header.Version, BinaryVersion)
+	}
+
+	width, height := int64(header.Width), int64(header.Height)
+	// Мир 0×N не содержит клеток, но make по его высоте всё равно выделил бы память
+	if (width == 0) != (height == 0) {
+		return fmt.Errorf("%w: %dx%d world has an empty side", ErrCorrupt, width, height)
+	}
+	if width > maxBinaryCells || height > maxBinaryCells || width*height > maxBinaryCells {
+		return fmt.Errorf("%w: %dx%d world is too large", ErrCorrupt, width, height)
+	}
+	cells := int(width * height)
+
+	var paletteSize uint16
+	if err := binary.Read(r, le, &paletteSize); err != nil {
+		return truncated("palette", err)
+	}
+	palette := make([]biome.Data, paletteSize)
+	for i := range palette {
+		var fields [3]string
+		for j := range fields {
+			var size uint16
+			if err := binary.Read(r, le, &size); err != nil {
+				return truncated("palette", err)
+			}
+			buf := make([]byte, size)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return truncated("palette", err)
+			}
+			fields[j] = string(buf)
+		}
+		palette[i] = biome.Data{Name: fields[0], NameRU: fields[1], Color: fields[2]}
+	}
+
+	layerCount, err := r.ReadByte()
+	if err != nil {
+		return truncated("layers", err)
+	}
+
+	var matrix [][]biome.Data
+	var heights, distance [][]float64
+	expected := map[byte]int{layerBiomes: cells * 2, layerHeights: cells * 8, layerDistance: cells * 8}
+	for i := 0; i < int(layerCount); i++ {
+		kind, raw, err := readLayer(r, expected)
+		if err != nil {
+			return err
+		}
+
+		switch kind {
+		case layerBiomes:
+			matrix = make([][]biome.Data, height)
+			for y := range matrix {
+				matrix[y] = make([]biome.Data, width)
+				for x := range matrix[y] {
+					idx := le.Uint16(raw[(y*int(width)+x)*2:])
+					if int(idx) >= len(palette) {
+						return fmt.Errorf("%w: biome index %d is outside the palette of %d", ErrCorrupt, idx, len(palette))
+					}
+					matrix[y][x] = palette[idx]
+				}
+			}
+		case layerHeights:
+			heights = decodeFloats(raw, width, height)
+		case layerDistance:
+			distance = decodeFloats(raw, width, height)
+		}
+	}
+
+	if matrix == nil {
+		return fmt.Errorf("%w: biome layer is missing", ErrCorrupt)
+	}
+
+	*w = World{
+		Width:    width,
+		Height:   height,
+		Seed:     int(header.Seed),
+		Matrix:   matrix,
+		Heights:  heights,
+		Distance: distance,
+	}
+
+	return nil
+}
+
+// encodeFloats и decodeFloats переводят слой float64 в байты слоя и обратно.
+func encodeFloats(layer [][]float64, cells int) []byte {
+	raw := make([]byte, 0, cells*8)
+	for _, row := range layer {
+		for _, v := range row {
+			raw = binary.LittleEndian.AppendUint64(raw, math.Float64bits(v))
+		}
+	}
+	return raw
+}
+
+func decodeFloats(raw []byte, width, height int64) [][]float64 {
+	layer := make([][]float64, height)
+	for y := range layer {
+		layer[y] = make([]float64, width)
+		for x := range layer[y] {
+			layer[y][x] = math.Float64frombits(binary.LittleEndian.Uint64(raw[(int64(y)*width+int64(x))*8:]))
+		}
+	}
+	return layer
+}
+
+// readLayer читает слой, если его вид есть в expected (вид → размер несжатых
+// данных), и пропускает неизвестные слои. Сжатые данные читаются потоком:
+// размеры из заголовка не используются для выделения памяти, пока не сверены
+// с размерами мира.
+func readLayer(r io.Reader, expected map[byte]int) (byte, []byte, error) {
+	var header struct {
+		Kind           byte
+		RawSize        uint32
+		Checksum       uint32
+		CompressedSize uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return 0, nil, truncated("layer header", err)
+	}
+
+	data := io.LimitReader(r, int64(header.CompressedSize))
+	size, known := expected[header.Kind]
+	if !known {
+		// Неизвестные слои из будущих версий пропускаем, не распаковывая
+		if _, err := io.Copy(io.Discard, data); err != nil {
+			return 0, nil, truncated("layer data", err)
+		}
+		if data.(*io.LimitedReader).N > 0 {
+			return 0, nil, truncated("layer data", io.ErrUnexpectedEOF)
+		}
+		return header.Kind, nil, nil
+	}
+	if int64(header.RawSize) != int64(size) {
+		return 0, nil, fmt.Errorf("%w: layer %d has %d bytes, expected %d", ErrCorrupt, header.Kind, header.RawSize, size)
+	}
+
+	zr, err := zlib.NewReader(data)
+	if err != nil {
+		return 0, nil, layerError(header.Kind, err)
+	}
+	raw := make([]byte, size)
+	if _, err := io.ReadFull(zr, raw); err != nil {
+		return 0, nil, layerError(header.Kind, err)
+	}
+	// Дочитываем поток до конца, чтобы zlib проверил свою контрольную сумму
+	// и лишние данные после заявленного размера не прошли незамеченными
+	if n, err := zr.Read(make([]byte, 1)); n != 0 {
+		return 0, nil, fmt.Errorf("%w: layer %d has unexpected trailing data", ErrCorrupt, header.Kind)
+	} else if err != io.EOF {
+		return 0, nil, layerError(header.Kind, err)
+	}
+	// Остаток сжатых данных за концом потока zlib не нужен, но сдвигает следующий слой
+	if _, err := io.Copy(io.Discard, data); err != nil {
+		return 0, nil, truncated("layer data", err)
+	}
+	if crc32.ChecksumIEEE(raw) != header.Checksum {
+		return 0, nil, fmt.Errorf("%w: layer %d checksum mismatch", ErrCorrupt, header.Kind)
+	}
+
+	return header.Kind, raw, nil
+}
+
+// layerError отличает обрыв файла внутри сжатых данных от испорченного потока.
+func layerError(kind byte, err error) error {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return truncated("layer data", err)
+	}
+	return fmt.Errorf("%w: layer %d: %v", ErrCorrupt, kind, err)
+}
+
+func truncated(section string, err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("world: truncated file while reading %s: %w", section, io.ErrUnexpectedEOF)
+	}
+	return fmt.Errorf("world: reading %s: %w", section, err)
+}
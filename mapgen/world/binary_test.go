@@ -0,0 +1,150 @@
+package world
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"runtime"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+)
+
+func testWorld() *World {
+	water := biome.Data{Name: "Liquid", NameRU: "Океан", Color: "#4292c4"}
+	land := biome.Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"}
+
+	matrix := make([][]biome.Data, 5)
+	heights := make([][]float64, 5)
+	for y := range matrix {
+		matrix[y] = make([]biome.Data, 7)
+		heights[y] = make([]float64, 7)
+		for x := range matrix[y] {
+			heights[y][x] = float64(x*y) / 24.0
+			if heights[y][x] < 0.3 {
+				matrix[y][x] = water
+			} else {
+				matrix[y][x] = land
+			}
+		}
+	}
+
+	return NewWorldWithHeights(matrix, heights, 42)
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	original := testWorld()
+
+	var buf bytes.Buffer
+	if err := original.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	var loaded World
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reflect.DeepEqual(original, &loaded) {
+		t.Fatalf("loaded world differs:\n got %+v\nwant %+v", loaded, original)
+	}
+}
+
+func TestBinaryKeepsDistanceLayer(t *testing.T) {
+	original := testWorld()
+	original.Distance = original.DistanceToCoast()
+	// Поле без целей — бесконечности, они тоже должны пережить сохранение
+	original.Distance[0][0] = math.Inf(1)
+
+	var buf bytes.Buffer
+	if err := original.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	var loaded World
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !loaded.HasDistance() || !reflect.DeepEqual(original.Distance, loaded.Distance) {
+		t.Fatalf("distance layer differs:\n got %v\nwant %v", loaded.Distance, original.Distance)
+	}
+}
+
+func TestBinaryRejectsBadInput(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testWorld().Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data := buf.Bytes()
+
+	var w World
+	if err := w.Load(bytes.NewReader(data[:len(data)-3])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated file: got %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	badMagic := append([]byte("PNG!"), data[4:]...)
+	if err := w.Load(bytes.NewReader(badMagic)); !errors.Is(err, ErrBadMagic) {
+		t.Errorf("bad magic: got %v, want ErrBadMagic", err)
+	}
+
+	corrupt := append([]byte(nil), data...)
+	corrupt[len(corrupt)-5] ^= 0xff
+	if err := w.Load(bytes.NewReader(corrupt)); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("corrupt layer: got %v, want ErrCorrupt", err)
+	}
+
+	// Нулевая ширина при огромной высоте: клеток нет, но строк — миллиарды
+	flat := layerFile(0, 2, []byte{0x78, 0x9c})
+	binary.LittleEndian.PutUint32(flat[6:], 0)
+	binary.LittleEndian.PutUint32(flat[10:], 0xFFFFFFF0)
+	if err := w.Load(bytes.NewReader(flat)); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("0x%d world: got %v, want ErrCorrupt", uint32(0xFFFFFFF0), err)
+	}
+}
+
+// layerFile собирает файл мира 2×1 с одним слоем биомов и заданным заголовком слоя.
+func layerFile(rawSize, compressedSize uint32, data []byte) []byte {
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	buf.Write(binaryMagic[:])
+	binary.Write(&buf, le, uint16(BinaryVersion))
+	binary.Write(&buf, le, uint32(2))
+	binary.Write(&buf, le, uint32(1))
+	binary.Write(&buf, le, int64(7))
+	binary.Write(&buf, le, uint16(1))
+	for _, s := range []string{"Liquid", "Океан", "#4292c4"} {
+		binary.Write(&buf, le, uint16(len(s)))
+		buf.WriteString(s)
+	}
+	buf.WriteByte(1)
+	buf.WriteByte(layerBiomes)
+	binary.Write(&buf, le, rawSize)
+	binary.Write(&buf, le, uint32(0))
+	binary.Write(&buf, le, compressedSize)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func TestBinaryRejectsOversizedLayerHeader(t *testing.T) {
+	for _, tc := range []struct {
+		name                    string
+		rawSize, compressedSize uint32
+		want                    error
+	}{
+		{"raw size", 1 << 30, 2, ErrCorrupt},
+		{"compressed size", 4, math.MaxUint32, io.ErrUnexpectedEOF},
+	} {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+
+		var w World
+		err := w.Load(bytes.NewReader(layerFile(tc.rawSize, tc.compressedSize, []byte{0x78, 0x9c})))
+		runtime.ReadMemStats(&after)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+			t.Errorf("%s: allocated %d bytes for a 2x1 world", tc.name, allocated)
+		}
+	}
+}
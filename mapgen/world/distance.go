@@ -0,0 +1,233 @@
+package world
+
+import (
+	"math"
+	"runtime"
+	"sync"
+	"tilemap-generator/mapgen/biome"
+)
+
+// Поля расстояний — слои той же формы, что Heights: евклидово расстояние
+// в клетках между центрами клеток. Считаются точным преобразованием
+// Фельзенсвальба — Хуттенлохера за O(W·H), столбцы и строки параллельно.
+// Каждый вызов возвращает новое поле; чтобы сохранить его вместе с миром,
+// присвойте его World.Distance.
+
+// DistanceTransform возвращает для каждой клетки расстояние до ближайшей клетки,
+// где target истинно; у самих таких клеток 0. Если таких клеток нет, везде +Inf.
+func (w *World) DistanceTransform(target func(biome.Data) bool) [][]float64 {
+	hit := cellClasses(w, func(b biome.Data) bool { return target(b) })
+	return w.distanceTo(func(i int) bool { return hit[i] })
+}
+
+// DistanceToWater — насколько клетка далека от воды; у водных клеток 0.
+func (w *World) DistanceToWater() [][]float64 {
+	return w.DistanceTransform(biome.Data.IsWater)
+}
+
+// DistanceToCoast — знаковое расстояние до клетки другого рода: на суше
+// положительное (до ближайшей воды), в воде отрицательное (до ближайшей суши).
+// Клетки у самого берега получают ±1.
+func (w *World) DistanceToCoast() [][]float64 {
+	water := cellClasses(w, biome.Data.IsWater)
+	toWater := w.distanceTo(func(i int) bool { return water[i] })
+	toLand := w.distanceTo(func(i int) bool { return !water[i] })
+	for y, row := range toWater {
+		for x := range row {
+			if row[x] == 0 {
+				row[x] = -toLand[y][x]
+			}
+		}
+	}
+	return toWater
+}
+
+// DistanceToBorder — расстояние до ближайшей пограничной клетки, то есть клетки,
+// у которой сосед по стороне другого класса; у пограничных клеток 0.
+// class как в LabelOptions: nil — каждый биом свой класс, ByGroup — группы.
+func (w *World) DistanceToBorder(class func(biome.Data) string) [][]float64 {
+	var ids []int32
+	if class == nil {
+		seen := make(map[biome.Data]int32)
+		ids = cellClasses(w, func(b biome.Data) int32 {
+			if _, ok := seen[b]; !ok {
+				seen[b] = int32(len(seen))
+			}
+			return seen[b]
+		})
+	} else {
+		seen := make(map[string]int32)
+		ids = cellClasses(w, func(b biome.Data) int32 {
+			name := class(b)
+			if _, ok := seen[name]; !ok {
+				seen[name] = int32(len(seen))
+			}
+			return seen[name]
+		})
+	}
+
+	width, n := int(w.Width), len(ids)
+	return w.distanceTo(func(i int) bool {
+		x, c := i%width, ids[i]
+		return (x > 0 && ids[i-1] != c) || (x+1 < width && ids[i+1] != c) ||
+			(i >= width && ids[i-width] != c) || (i+width < n && ids[i+width] != c)
+	})
+}
+
+// cellClasses применяет f к биому каждой клетки построчно. Соседние клетки
+// обычно одного биома, поэтому ответ для последнего биома запоминается —
+// иначе на больших картах время уходит на сравнение и хеширование строк.
+func cellClasses[T any](w *World, f func(biome.Data) T) []T {
+	out := make([]T, w.Width*w.Height)
+	cache := make(map[biome.Data]T)
+	var last biome.Data
+	var lastValue T
+	primed := false
+	i := 0
+	for _, row := range w.Matrix {
+		for _, b := range row {
+			if !primed || b != last {
+				v, ok := cache[b]
+				if !ok {
+					v = f(b)
+					cache[b] = v
+				}
+				last, lastValue, primed = b, v, true
+			}
+			out[i] = lastValue
+			i++
+		}
+	}
+	return out
+}
+
+// distanceTo считает поле расстояний до клеток, где target(y*Width+x) истинно.
+func (w *World) distanceTo(target func(i int) bool) [][]float64 {
+	width, height := int(w.Width), int(w.Height)
+	inf := math.Inf(1)
+
+	// Расстояния в одном массиве, строки слоя — его срезы
+	grid := make([]float64, width*height)
+	for i := range grid {
+		if !target(i) {
+			grid[i] = inf
+		}
+	}
+
+	// По столбцам для двоичной маски хватает двух проходов сверху и снизу.
+	// Они идут построчно, чтобы читать память подряд, а не с шагом в строку
+	parallel(width, func(lo, hi int) {
+		for y := 1; y < height; y++ {
+			row, prev := grid[y*width:], grid[(y-1)*width:]
+			for x := lo; x < hi; x++ {
+				if d := prev[x] + 1; d < row[x] {
+					row[x] = d
+				}
+			}
+		}
+		for y := height - 2; y >= 0; y-- {
+			row, next := grid[y*width:], grid[(y+1)*width:]
+			for x := lo; x < hi; x++ {
+				if d := next[x] + 1; d < row[x] {
+					row[x] = d
+				}
+			}
+		}
+	})
+	// По строкам — нижняя огибающая парабол от квадратов столбцовых расстояний
+	parallel(height, func(lo, hi int) {
+		e := newEnvelope(width)
+		for y := lo; y < hi; y++ {
+			row := grid[y*width : (y+1)*width]
+			for x, g := range row {
+				e.f[x] = g * g
+			}
+			e.transform()
+			for x, d := range e.d {
+				row[x] = math.Sqrt(d)
+			}
+		}
+	})
+
+	layer := make([][]float64, height)
+	for y := range layer {
+		layer[y] = grid[y*width : (y+1)*width : (y+1)*width]
+	}
+	return layer
+}
+
+// envelope — буферы одномерного преобразования: нижняя огибающая парабол
+// с вершинами в (q, f[q]).
+type envelope struct {
+	f, d []float64
+	v    []int
+	z    []float64
+}
+
+func newEnvelope(n int) *envelope {
+	return &envelope{
+		f: make([]float64, n),
+		d: make([]float64, n),
+		v: make([]int, n),
+		z: make([]float64, n+1),
+	}
+}
+
+// transform считает d[q] = min over p of (q-p)² + f[p]. Бесконечные f
+// в огибающую не попадают, чтобы не получить Inf - Inf.
+func (e *envelope) transform() {
+	k := -1
+	for q, fq := range e.f {
+		if math.IsInf(fq, 1) {
+			continue
+		}
+		var s float64
+		for {
+			if k < 0 {
+				s = math.Inf(-1)
+				break
+			}
+			p := e.v[k]
+			s = ((fq + float64(q*q)) - (e.f[p] + float64(p*p))) / float64(2*(q-p))
+			if s > e.z[k] {
+				break
+			}
+			k--
+		}
+		k++
+		e.v[k], e.z[k], e.z[k+1] = q, s, math.Inf(1)
+	}
+
+	if k < 0 {
+		for q := range e.d {
+			e.d[q] = math.Inf(1)
+		}
+		return
+	}
+	k = 0
+	for q := range e.d {
+		for e.z[k+1] < float64(q) {
+			k++
+		}
+		p := e.v[k]
+		e.d[q] = float64((q-p)*(q-p)) + e.f[p]
+	}
+}
+
+// parallel делит [0, n) на части по числу процессоров.
+func parallel(n int, fn func(lo, hi int)) {
+	parts := min(runtime.NumCPU(), n)
+	if parts <= 1 {
+		fn(0, n)
+		return
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < parts; i++ {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			fn(lo, hi)
+		}(i*n/parts, (i+1)*n/parts)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,113 @@
+package world
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+)
+
+// bruteDistance — расстояние перебором всех клеток, эталон для проверки.
+func bruteDistance(w *World, target func(biome.Data) bool) [][]float64 {
+	field := make([][]float64, w.Height)
+	for y := range field {
+		field[y] = make([]float64, w.Width)
+		for x := range field[y] {
+			best := math.Inf(1)
+			w.Each(func(p Point, b biome.Data) bool {
+				if target(b) {
+					best = math.Min(best, math.Hypot(float64(p.X-int64(x)), float64(p.Y-int64(y))))
+				}
+				return true
+			})
+			field[y][x] = best
+		}
+	}
+	return field
+}
+
+func TestDistanceTransformMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, density := range []float64{0, 0.01, 0.2, 0.7} {
+		matrix := make([][]biome.Data, 23)
+		for y := range matrix {
+			matrix[y] = make([]biome.Data, 37)
+			for x := range matrix[y] {
+				matrix[y][x] = testField
+				if rng.Float64() < density {
+					matrix[y][x] = testSea
+				}
+			}
+		}
+		w := NewWorld(matrix, 1)
+
+		got := w.DistanceToWater()
+		want := bruteDistance(w, biome.Data.IsWater)
+		for y := range want {
+			for x := range want[y] {
+				if math.Abs(got[y][x]-want[y][x]) > 1e-9 && got[y][x] != want[y][x] {
+					t.Fatalf("density %v: (%d, %d) = %v, want %v", density, x, y, got[y][x], want[y][x])
+				}
+			}
+		}
+	}
+}
+
+func TestDistanceToCoastAndBorder(t *testing.T) {
+	w := parseRows(
+		"~~~~~~",
+		"~....~",
+		"~..o.~",
+		"~....~",
+		"~~~~~~",
+	)
+
+	coast := w.DistanceToCoast()
+	for _, tc := range []struct {
+		p    Point
+		want float64
+	}{
+		{Point{0, 0}, -math.Sqrt2},
+		{Point{1, 1}, 1},
+		{Point{2, 2}, 1}, // озеро тоже вода
+		{Point{3, 2}, -1},
+		{Point{0, 2}, -1},
+	} {
+		if got := coast[tc.p.Y][tc.p.X]; got != tc.want {
+			t.Errorf("coast at %v = %v, want %v", tc.p, got, tc.want)
+		}
+	}
+
+	// Между морем и озером граница биомов есть, а групп — нет
+	w = parseRows(
+		"~~oo..",
+		"~~oo..",
+	)
+	byBiome := w.DistanceToBorder(nil)
+	byGroup := w.DistanceToBorder(ByGroup)
+	if byBiome[0][1] != 0 || byBiome[0][0] != 1 || byGroup[0][1] != 2 || byGroup[0][0] != 3 {
+		t.Errorf("border by biome %v, by group %v", byBiome[0], byGroup[0])
+	}
+}
+
+func BenchmarkDistanceToCoast4k(b *testing.B) {
+	const size = 4096
+	matrix := make([][]biome.Data, size)
+	for y := range matrix {
+		matrix[y] = make([]biome.Data, size)
+		for x := range matrix[y] {
+			// Шахматная доска из квадратов суши и воды 300×300
+			if (x/300+y/300)%2 == 0 {
+				matrix[y][x] = testSea
+			} else {
+				matrix[y][x] = testField
+			}
+		}
+	}
+	w := NewWorld(matrix, 1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.DistanceToCoast()
+	}
+}
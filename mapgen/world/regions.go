@@ -0,0 +1,240 @@
+package world
+
+import (
+	"sort"
+	"tilemap-generator/mapgen/biome"
+)
+
+// Rect — прямоугольник клеток [Min, Max), как у EachIn.
+type Rect struct {
+	Min, Max Point
+}
+
+type LabelOptions struct {
+	// 4 — соседи по сторонам, 8 — ещё и по диагонали. Default: 4.
+	Connectivity int
+	// Класс биома: соседние клетки одного класса попадают в одну область.
+	// Default: каждый биом — свой класс. Готовые варианты: ByGroup, ByLand.
+	Class func(biome.Data) string
+}
+
+// ByGroup объединяет биомы одной группы (Name): все горы — один хребет.
+func ByGroup(b biome.Data) string {
+	return b.Name
+}
+
+// ByLand делит мир на сушу и воду: области — материки, острова, моря и озёра.
+func ByLand(b biome.Data) string {
+	if b.IsWater() {
+		return "water"
+	}
+	return "land"
+}
+
+// Region — связная область одного класса.
+type Region struct {
+	ID int
+	// Класс из LabelOptions.Class; пусто без него.
+	Class string
+	// Биом первой клетки области (при обходе построчно).
+	Biome biome.Data
+	Size  int64
+	// Наименьший прямоугольник, содержащий область.
+	Bounds Rect
+	// Среднее координат клеток.
+	CentroidX, CentroidY float64
+	// Области, с которыми есть общие стороны клеток, по возрастанию ID.
+	Neighbors []Neighbor
+}
+
+type Neighbor struct {
+	ID int
+	// Длина общей границы — число общих сторон клеток.
+	Border int64
+}
+
+// Edge — ребро графа смежности областей, A < B.
+type Edge struct {
+	A, B   int
+	Border int64
+}
+
+// Regions — разметка мира на связные области.
+type Regions struct {
+	Width, Height int64
+	// Labels[y*Width+x] — ID области клетки, он же индекс в List.
+	Labels []int32
+	// В порядке первой клетки при обходе построчно.
+	List []Region
+}
+
+// Label размечает мир на связные области. Области одного класса при 4-связности
+// могут касаться углами, но границей считаются только общие стороны клеток.
+func (w *World) Label(opts LabelOptions) *Regions {
+	width, height := int(w.Width), int(w.Height)
+	rs := &Regions{Width: w.Width, Height: w.Height, Labels: make([]int32, width*height)}
+
+	// Класс считается один раз на биом, дальше сравниваются числа
+	classes := make([]int32, width*height)
+	classOf := make(map[biome.Data]int32)
+	classNames := []string{}
+	ids := make(map[string]int32)
+	for _, b := range w.Palette() {
+		if opts.Class == nil {
+			classOf[b] = int32(len(classNames))
+			classNames = append(classNames, "")
+			continue
+		}
+		name := opts.Class(b)
+		id, ok := ids[name]
+		if !ok {
+			id = int32(len(classNames))
+			ids[name] = id
+			classNames = append(classNames, name)
+		}
+		classOf[b] = id
+	}
+	for y, row := range w.Matrix {
+		for x, b := range row {
+			classes[y*width+x] = classOf[b]
+		}
+	}
+
+	offsets := [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	if opts.Connectivity == 8 {
+		offsets = append(offsets, [2]int{-1, -1}, [2]int{1, -1}, [2]int{-1, 1}, [2]int{1, 1})
+	}
+
+	for i := range rs.Labels {
+		rs.Labels[i] = -1
+	}
+	var stack []int
+	for start := range rs.Labels {
+		if rs.Labels[start] >= 0 {
+			continue
+		}
+		id := int32(len(rs.List))
+		class := classes[start]
+		sx, sy := int64(start%width), int64(start/width)
+		r := Region{
+			ID:     int(id),
+			Class:  classNames[class],
+			Biome:  w.Matrix[sy][sx],
+			Bounds: Rect{Point{sx, sy}, Point{sx + 1, sy + 1}},
+		}
+		var sumX, sumY int64
+
+		rs.Labels[start] = id
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			x, y := i%width, i/width
+			r.Size++
+			sumX += int64(x)
+			sumY += int64(y)
+			r.Bounds.Min.X, r.Bounds.Min.Y = min(r.Bounds.Min.X, int64(x)), min(r.Bounds.Min.Y, int64(y))
+			r.Bounds.Max.X, r.Bounds.Max.Y = max(r.Bounds.Max.X, int64(x)+1), max(r.Bounds.Max.Y, int64(y)+1)
+
+			for _, o := range offsets {
+				nx, ny := x+o[0], y+o[1]
+				if nx < 0 || nx >= width || ny < 0 || ny >= height {
+					continue
+				}
+				j := ny*width + nx
+				if rs.Labels[j] < 0 && classes[j] == class {
+					rs.Labels[j] = id
+					stack = append(stack, j)
+				}
+			}
+		}
+
+		r.CentroidX = float64(sumX) / float64(r.Size)
+		r.CentroidY = float64(sumY) / float64(r.Size)
+		rs.List = append(rs.List, r)
+	}
+
+	rs.linkNeighbors()
+	return rs
+}
+
+// linkNeighbors считает общие стороны клеток разных областей: каждую сторону
+// один раз, у правого и нижнего соседа.
+func (rs *Regions) linkNeighbors() {
+	width, height := int(rs.Width), int(rs.Height)
+	borders := make(map[[2]int32]int64)
+	add := func(a, b int32) {
+		if a == b {
+			return
+		}
+		if a > b {
+			a, b = b, a
+		}
+		borders[[2]int32{a, b}]++
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			if x+1 < width {
+				add(rs.Labels[i], rs.Labels[i+1])
+			}
+			if y+1 < height {
+				add(rs.Labels[i], rs.Labels[i+width])
+			}
+		}
+	}
+
+	for pair, border := range borders {
+		a, b := &rs.List[pair[0]], &rs.List[pair[1]]
+		a.Neighbors = append(a.Neighbors, Neighbor{b.ID, border})
+		b.Neighbors = append(b.Neighbors, Neighbor{a.ID, border})
+	}
+	for i := range rs.List {
+		n := rs.List[i].Neighbors
+		sort.Slice(n, func(i, j int) bool { return n[i].ID < n[j].ID })
+	}
+}
+
+// At возвращает область клетки.
+func (rs *Regions) At(p Point) *Region {
+	return &rs.List[rs.Labels[p.Y*rs.Width+p.X]]
+}
+
+// Cells возвращает клетки области построчно.
+func (rs *Regions) Cells(r *Region) []Point {
+	cells := make([]Point, 0, r.Size)
+	for y := r.Bounds.Min.Y; y < r.Bounds.Max.Y; y++ {
+		for x := r.Bounds.Min.X; x < r.Bounds.Max.X; x++ {
+			if int(rs.Labels[y*rs.Width+x]) == r.ID {
+				cells = append(cells, Point{x, y})
+			}
+		}
+	}
+	return cells
+}
+
+// Edges возвращает граф смежности списком рёбер по возрастанию (A, B).
+func (rs *Regions) Edges() []Edge {
+	var edges []Edge
+	for _, r := range rs.List {
+		for _, n := range r.Neighbors {
+			if r.ID < n.ID {
+				edges = append(edges, Edge{r.ID, n.ID, n.Border})
+			}
+		}
+	}
+	return edges
+}
+
+// Where возвращает области, подходящие под условие, например острова —
+// участки суши, окружённые одной водной областью.
+func (rs *Regions) Where(match func(r *Region) bool) []*Region {
+	var found []*Region
+	for i := range rs.List {
+		if match(&rs.List[i]) {
+			found = append(found, &rs.List[i])
+		}
+	}
+	return found
+}
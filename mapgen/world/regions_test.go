@@ -0,0 +1,101 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+)
+
+var (
+	testSea   = biome.Data{Name: biome.WaterGroup, NameRU: "Океан", Color: "#4292c4"}
+	testLake  = biome.Data{Name: biome.WaterGroup, NameRU: "Озеро", Color: "#5aa0d0"}
+	testField = biome.Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"}
+)
+
+// parseRows строит мир по схеме: '~' — море, 'o' — озеро, '.' — луга.
+// Тот же разбор, что worldtest.ParseGrid: импортировать его отсюда нельзя из-за цикла.
+func parseRows(rows ...string) *World {
+	legend := map[rune]biome.Data{'~': testSea, 'o': testLake, '.': testField}
+	matrix := make([][]biome.Data, len(rows))
+	for y, row := range rows {
+		for _, ch := range row {
+			matrix[y] = append(matrix[y], legend[ch])
+		}
+	}
+	return NewWorld(matrix, 1)
+}
+
+func TestLabelConnectivity(t *testing.T) {
+	w := parseRows(
+		"..~~",
+		"..~~",
+		"~~..",
+		"~~..",
+	)
+
+	four := w.Label(LabelOptions{})
+	if len(four.List) != 4 {
+		t.Fatalf("4-connectivity: %d regions, want 4", len(four.List))
+	}
+	// Поля касаются только углом — общей границы нет, у каждого поля два соседа-моря
+	for _, r := range four.List {
+		if len(r.Neighbors) != 2 || r.Neighbors[0].Border != 2 {
+			t.Errorf("region %d neighbors %+v", r.ID, r.Neighbors)
+		}
+	}
+
+	eight := w.Label(LabelOptions{Connectivity: 8})
+	// По диагонали соединяются и поля, и моря
+	if len(eight.List) != 2 {
+		t.Fatalf("8-connectivity: %d regions, want 2", len(eight.List))
+	}
+	fields := eight.At(Point{0, 0})
+	if fields != eight.At(Point{3, 3}) || fields.Size != 8 {
+		t.Errorf("diagonal fields are not one region: %+v", fields)
+	}
+	if fields.CentroidX != 1.5 || fields.CentroidY != 1.5 {
+		t.Errorf("centroid (%v, %v), want (1.5, 1.5)", fields.CentroidX, fields.CentroidY)
+	}
+	if want := (Rect{Point{0, 0}, Point{4, 4}}); fields.Bounds != want {
+		t.Errorf("bounds %+v, want %+v", fields.Bounds, want)
+	}
+}
+
+func TestLabelClassesAndGraph(t *testing.T) {
+	w := parseRows(
+		"~~~~~~~",
+		"~.....~",
+		"~..o..~",
+		"~.....~",
+		"~~~~~~~",
+	)
+
+	byBiome := w.Label(LabelOptions{})
+	lake := byBiome.At(Point{3, 2})
+	if lake.Biome != testLake || lake.Size != 1 {
+		t.Fatalf("lake %+v", lake)
+	}
+	if got := byBiome.Cells(lake); !reflect.DeepEqual(got, []Point{{3, 2}}) {
+		t.Errorf("lake cells %v", got)
+	}
+	island := byBiome.At(Point{1, 1})
+	if island.Size != 14 {
+		t.Errorf("island size %d, want 14", island.Size)
+	}
+	// Остров граничит с морем 16 сторонами и с озером четырьмя
+	want := []Edge{{0, 1, 16}, {1, 2, 4}}
+	if got := byBiome.Edges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("edges %+v, want %+v", got, want)
+	}
+
+	// По группам озеро и море — одна вода, но не связная: две водные области
+	byGroup := w.Label(LabelOptions{Class: ByGroup})
+	water := byGroup.Where(func(r *Region) bool { return r.Class == biome.WaterGroup })
+	if len(water) != 2 {
+		t.Errorf("%d water regions, want 2", len(water))
+	}
+	land := w.Label(LabelOptions{Class: ByLand}).Where(func(r *Region) bool { return r.Class == "land" })
+	if len(land) != 1 || land[0].Size != 14 {
+		t.Errorf("land regions %+v", land)
+	}
+}
@@ -0,0 +1,199 @@
+package world
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"tilemap-generator/mapgen/biome"
+)
+
+type StatsOptions struct {
+	// Число равных интервалов гистограммы высот на [0, 1]. Default: 10.
+	HistogramBins int
+}
+
+// Stats — сводка мира для балансировки и регрессионных проверок:
+// одинаковый мир всегда даёт одинаковую сводку.
+type Stats struct {
+	Width  int64 `json:"width"`
+	Height int64 `json:"height"`
+	Seed   int   `json:"seed"`
+
+	LandCells  int64   `json:"land_cells"`
+	WaterCells int64   `json:"water_cells"`
+	LandRatio  float64 `json:"land_ratio"`
+	// Длина береговой линии — число сторон клеток между сушей и водой.
+	Coastline int64 `json:"coastline"`
+
+	// В порядке Palette.
+	Biomes []BiomeStats `json:"biomes"`
+	// nil, если у мира нет слоя высот.
+	Elevation *ElevationStats `json:"elevation,omitempty"`
+}
+
+type BiomeStats struct {
+	biome.Data
+	Cells   int64   `json:"cells"`
+	Percent float64 `json:"percent"`
+	// Связные по сторонам области биома.
+	Regions RegionStats `json:"regions"`
+}
+
+type RegionStats struct {
+	Count  int     `json:"count"`
+	Min    int64   `json:"min"`
+	Max    int64   `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	// Buckets[i] — число областей размером от 2^i до 2^(i+1)-1 клеток.
+	Buckets []int `json:"buckets"`
+}
+
+type ElevationStats struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+	// Число клеток в каждом из равных интервалов [0, 1].
+	Histogram []int64 `json:"histogram"`
+}
+
+// Stats считает доли биомов, сушу и воду, береговую линию, области биомов
+// и распределение высот.
+func (w *World) Stats(opts StatsOptions) Stats {
+	if opts.HistogramBins <= 0 {
+		opts.HistogramBins = 10
+	}
+
+	s := Stats{Width: w.Width, Height: w.Height, Seed: w.Seed}
+	counts := make(map[biome.Data]int64)
+	w.Each(func(p Point, b biome.Data) bool {
+		counts[b]++
+		if b.IsWater() {
+			s.WaterCells++
+		} else {
+			s.LandCells++
+		}
+		// Каждую сторону считаем один раз: у правого и нижнего соседа
+		if p.X+1 < w.Width && b.IsWater() != w.Matrix[p.Y][p.X+1].IsWater() {
+			s.Coastline++
+		}
+		if p.Y+1 < w.Height && b.IsWater() != w.Matrix[p.Y+1][p.X].IsWater() {
+			s.Coastline++
+		}
+		return true
+	})
+
+	total := w.Width * w.Height
+	if total > 0 {
+		s.LandRatio = float64(s.LandCells) / float64(total)
+	}
+
+	regions := make(map[biome.Data][]int64)
+	for _, r := range w.Label(LabelOptions{}).List {
+		regions[r.Biome] = append(regions[r.Biome], r.Size)
+	}
+	for _, b := range w.Palette() {
+		s.Biomes = append(s.Biomes, BiomeStats{
+			Data:    b,
+			Cells:   counts[b],
+			Percent: float64(counts[b]) / float64(total) * 100,
+			Regions: newRegionStats(regions[b]),
+		})
+	}
+
+	if w.HasHeights() {
+		s.Elevation = w.elevationStats(opts.HistogramBins)
+	}
+
+	return s
+}
+
+func newRegionStats(sizes []int64) RegionStats {
+	if len(sizes) == 0 {
+		return RegionStats{}
+	}
+	sorted := append([]int64(nil), sizes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rs := RegionStats{Count: len(sorted), Min: sorted[0], Max: sorted[len(sorted)-1]}
+	var sum int64
+	for _, size := range sorted {
+		sum += size
+		bucket := bits.Len64(uint64(size)) - 1
+		for len(rs.Buckets) <= bucket {
+			rs.Buckets = append(rs.Buckets, 0)
+		}
+		rs.Buckets[bucket]++
+	}
+	rs.Mean = float64(sum) / float64(len(sorted))
+	if n := len(sorted); n%2 == 1 {
+		rs.Median = float64(sorted[n/2])
+	} else {
+		rs.Median = float64(sorted[n/2-1]+sorted[n/2]) / 2
+	}
+	return rs
+}
+
+func (w *World) elevationStats(bins int) *ElevationStats {
+	// Без клеток ±Inf и NaN сломали бы JSON — оставляем нули
+	if w.Width*w.Height == 0 {
+		return &ElevationStats{Histogram: make([]int64, bins)}
+	}
+	e := &ElevationStats{Min: math.Inf(1), Max: math.Inf(-1), Histogram: make([]int64, bins)}
+	sum := 0.0
+	for _, row := range w.Heights {
+		for _, h := range row {
+			e.Min = math.Min(e.Min, h)
+			e.Max = math.Max(e.Max, h)
+			sum += h
+			bin := int(h * float64(bins))
+			e.Histogram[max(0, min(bin, bins-1))]++
+		}
+	}
+	e.Mean = sum / float64(w.Width*w.Height)
+	return e
+}
+
+// WriteTable печатает сводку в виде таблиц для чтения человеком.
+func (s Stats) WriteTable(out io.Writer) error {
+	fmt.Fprintf(out, "World %dx%d, seed %d\n", s.Width, s.Height, s.Seed)
+	fmt.Fprintf(out, "Land %.2f%% (%d cells), water %.2f%% (%d cells), coastline %d\n",
+		s.LandRatio*100, s.LandCells, (1-s.LandRatio)*100, s.WaterCells, s.Coastline)
+	if s.Elevation != nil {
+		fmt.Fprintf(out, "Elevation %.3f..%.3f, mean %.3f\n", s.Elevation.Min, s.Elevation.Max, s.Elevation.Mean)
+	}
+	fmt.Fprintln(out)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Biome\tGroup\tCells\tShare\tRegions\tLargest\tMedian\t")
+	for _, b := range s.Biomes {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f%%\t%d\t%d\t%g\t\n",
+			b.NameRU, b.Name, b.Cells, b.Percent, b.Regions.Count, b.Regions.Max, b.Regions.Median)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if s.Elevation == nil {
+		return nil
+	}
+	fmt.Fprintln(out, "\nHeight histogram")
+	var peak int64
+	for _, n := range s.Elevation.Histogram {
+		peak = max(peak, n)
+	}
+	bins := float64(len(s.Elevation.Histogram))
+	tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for i, n := range s.Elevation.Histogram {
+		bar := 0
+		if peak > 0 {
+			bar = int(math.Round(float64(n) / float64(peak) * 40))
+		}
+		fmt.Fprintf(tw, "%.2f-%.2f\t%d\t%s\n", float64(i)/bins, float64(i+1)/bins, n, strings.Repeat("#", bar))
+	}
+	return tw.Flush()
+}
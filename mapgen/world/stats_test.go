@@ -0,0 +1,80 @@
+package world
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+)
+
+func TestStats(t *testing.T) {
+	// Остров из двух клеток и отдельная клетка суши в углу
+	w := parseRows(
+		"~~~~.",
+		"~..~~",
+		"~~~~~",
+	)
+	w.Seed = 3
+	w.Heights = make([][]float64, w.Height)
+	for y := range w.Heights {
+		for x := range w.Width {
+			w.Heights[y] = append(w.Heights[y], float64(x)/4)
+		}
+	}
+
+	s := w.Stats(StatsOptions{HistogramBins: 4})
+	if s.LandCells != 3 || s.WaterCells != 12 {
+		t.Errorf("land %d, water %d", s.LandCells, s.WaterCells)
+	}
+	// Остров: 6 сторон; угловая клетка: 2 стороны внутри мира
+	if s.Coastline != 8 {
+		t.Errorf("coastline %d, want 8", s.Coastline)
+	}
+
+	if len(s.Biomes) != 2 || s.Biomes[0].Data != testSea {
+		t.Fatalf("biomes %+v", s.Biomes)
+	}
+	land := s.Biomes[1].Regions
+	want := RegionStats{Count: 2, Min: 1, Max: 2, Mean: 1.5, Median: 1.5, Buckets: []int{1, 1}}
+	if !reflect.DeepEqual(land, want) {
+		t.Errorf("land regions %+v, want %+v", land, want)
+	}
+	if n := s.Biomes[0].Regions.Count; n != 1 {
+		t.Errorf("sea regions %d, want 1", n)
+	}
+
+	e := s.Elevation
+	if e == nil || e.Min != 0 || e.Max != 1 || !reflect.DeepEqual(e.Histogram, []int64{3, 3, 3, 6}) {
+		t.Errorf("elevation %+v", e)
+	}
+
+	var table bytes.Buffer
+	if err := s.WriteTable(&table); err != nil {
+		t.Fatalf("WriteTable: %v", err)
+	}
+	if !strings.Contains(table.String(), "Луга") || !strings.Contains(table.String(), "coastline 8") {
+		t.Errorf("table:\n%s", table.String())
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var decoded Stats
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(decoded, s) {
+		t.Errorf("JSON round trip differs: %v", err)
+	}
+}
+
+func TestStatsOfEmptyWorldIsValidJSON(t *testing.T) {
+	w := &World{Width: 0, Height: 1, Matrix: [][]biome.Data{{}}, Heights: [][]float64{{}}}
+	s := w.Stats(StatsOptions{})
+	if s.Elevation == nil || s.Elevation.Mean != 0 {
+		t.Errorf("elevation %+v", s.Elevation)
+	}
+	if _, err := json.Marshal(s); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+}
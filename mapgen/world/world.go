@@ -10,42 +10,51 @@ type Point struct {
 }
 
 type Config struct {
-	Width, Height int64
+	Width  int64 `json:"width"`
+	Height int64 `json:"height"`
 
 	// Частота изменения биомов по карте.
 	// Определяет, насколько часто будут встречаться переходы между биомами.
 	// Значение от 0.0 до 1.0, где 0.0 - изменения почти не происходят, а 1.0 - переходы между биомами максимально частые.
 	// Default: 0.3 (умеренная частота изменения).
-	FrequencyChange float64
+	FrequencyChange float64 `json:"frequency_change"`
 
 	// Плавность переходов между биомами, т.е. насколько резкими или мягкими будут границы биомов.
 	// 0.0 означает резкие, чёткие границы, а 1.0 — плавные, едва заметные переходы.
 	// Default: 0.5 (средняя плавность).
-	BorderSmoothness float64
+	BorderSmoothness float64 `json:"border_smoothness"`
 
 	// Перераспределение высот биомов.
 	// Значение определяет, насколько высоты биомов могут быть изменены или распределены по карте.
 	// Чем выше значение, тем сильнее могут варьироваться высоты биомов в пределах заданного диапазона.
 	// Default: 1.0 (среднее перераспределение высот).
 	// Min: 0.5 (меньшее изменение высот), Max: 1.5 (большее изменение высот).
-	HeightRedistribution float64
+	HeightRedistribution float64 `json:"height_redistribution"`
 
 	// Параметр Falloff влияет на область смягчения или исчезновения биомов.
 	// Он определяет, как сильно границы биомов будут "размазываться" на определённом расстоянии от границ.
 	// Значение 0.0 указывает на отсутствие области перехода, а большие значения создают более широкие области для сглаживания.
 	// Default: 0.0 (плавное падение).
-	Falloff float64
+	Falloff float64 `json:"falloff"`
 
 	// Если включена эта опция, высоты биомов будут усредняться, чтобы уменьшить резкие перепады между биомами.
 	// Это может помочь создать более естественные ландшафты с мягкими переходами.
 	// Default: true (включено).
-	HeightAveraging bool
+	HeightAveraging bool `json:"height_averaging"`
 }
 
 type World struct {
 	Width, Height int64
 	Seed          int
 	Matrix        [][]biome.Data // todo rewrite from T to something else
+
+	// Слой высот: нормализованная высота [0, 1] для каждой клетки.
+	// nil, если мир собран только из биомов.
+	Heights [][]float64
+
+	// Слой поля расстояний в клетках, например w.DistanceToCoast(); сохраняется
+	// в файл мира, как высоты. nil, если поле не посчитано.
+	Distance [][]float64
 }
 
 func NewWorld(matrix [][]biome.Data, seed int) *World {
@@ -69,9 +78,25 @@ func NewWorld(matrix [][]biome.Data, seed int) *World {
 	}
 }
 
+func NewWorldWithHeights(matrix [][]biome.Data, heights [][]float64, seed int) *World {
+	w := NewWorld(matrix, seed)
+	w.Heights = heights
+
+	return w
+}
+
 func (w *World) Each(callback func(point Point, biome biome.Data) bool) {
-	for y := int64(0); y < w.Height; y++ {
-		for x := int64(0); x < w.Width; x++ {
+	w.EachIn(Point{0, 0}, Point{w.Width, w.Height}, callback)
+}
+
+// EachIn обходит прямоугольник [min, max) построчно, как Each.
+// Границы обрезаются по размерам мира.
+func (w *World) EachIn(min, max Point, callback func(point Point, biome biome.Data) bool) {
+	min.X, min.Y = clamp(min.X, 0, w.Width), clamp(min.Y, 0, w.Height)
+	max.X, max.Y = clamp(max.X, 0, w.Width), clamp(max.Y, 0, w.Height)
+
+	for y := min.Y; y < max.Y; y++ {
+		for x := min.X; x < max.X; x++ {
 			res := callback(Point{x, y}, w.Matrix[y][x])
 			if !res {
 				return
@@ -80,6 +105,16 @@ func (w *World) Each(callback func(point Point, biome biome.Data) bool) {
 	}
 }
 
+func clamp(v, lo, hi int64) int64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
 func (w *World) GetAt(point Point) biome.Data {
 	return w.Matrix[point.Y][point.X]
 }
@@ -87,3 +122,31 @@ func (w *World) GetAt(point Point) biome.Data {
 func (w *World) ReplaceAt(point Point, data biome.Data) {
 	w.Matrix[point.Y][point.X] = data
 }
+
+func (w *World) HasHeights() bool {
+	return len(w.Heights) == int(w.Height) && w.Height > 0 && len(w.Heights[0]) == int(w.Width)
+}
+
+func (w *World) HasDistance() bool {
+	return len(w.Distance) == int(w.Height) && w.Height > 0 && len(w.Distance[0]) == int(w.Width)
+}
+
+func (w *World) HeightAt(point Point) float64 {
+	return w.Heights[point.Y][point.X]
+}
+
+// Palette возвращает уникальные биомы мира в порядке первого появления.
+func (w *World) Palette() []biome.Data {
+	seen := make(map[biome.Data]bool)
+	palette := make([]biome.Data, 0)
+
+	w.Each(func(_ Point, b biome.Data) bool {
+		if !seen[b] {
+			seen[b] = true
+			palette = append(palette, b)
+		}
+		return true
+	})
+
+	return palette
+}
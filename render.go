@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	stdimage "image"
+	"sort"
+	"strings"
+	"tilemap-generator/image"
+)
+
+func (c *cli) render(args []string) error {
+	fs := c.flags("render")
+	var src source
+	src.register(fs)
+	style := fs.String("style", "flat", "flat, shaded, contours, annotated, parchment or heightmap")
+	out := fs.String("out", "render.png", "output PNG file")
+	scale := fs.Int("scale", 0, "pixels per cell for contours, annotated and parchment (default depends on style)")
+	ao := fs.Bool("ao", false, "shaded: add ambient occlusion")
+	interval := fs.Float64("interval", 0, "contours: height step between lines (default 0.05)")
+	grid := fs.Int64("grid", 0, "annotated: coordinate grid step in cells, 0 disables the grid")
+	caption := fs.String("caption", "", "annotated: caption text")
+	palette := fs.String("palette", "sepia", "parchment: palette name")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	// Неверный стиль и палитру проверяем до генерации мира
+	pal, ok := image.ParchmentPalettes[*palette]
+	if !ok {
+		names := make([]string, 0, len(image.ParchmentPalettes))
+		for name := range image.ParchmentPalettes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return usageErrorf("unknown palette %q, want one of %s", *palette, strings.Join(names, ", "))
+	}
+	switch *style {
+	case "flat", "shaded", "contours", "annotated", "parchment", "heightmap":
+	default:
+		return usageErrorf("unknown style %q", *style)
+	}
+
+	w, err := src.load()
+	if err != nil {
+		return err
+	}
+
+	var img stdimage.Image
+	switch *style {
+	case "flat":
+		img = image.CreateImageFromWorld(w)
+	case "shaded":
+		opts := image.NewShadeOptions()
+		opts.AmbientOcclusion = *ao
+		img, err = image.CreateShadedImageFromWorld(w, opts)
+	case "contours":
+		img, err = image.CreateContourImageFromWorld(w, image.ContourOptions{Interval: *interval, Scale: *scale})
+	case "annotated":
+		img, err = image.CreateAnnotatedImage(w, image.AnnotateOptions{Scale: *scale, Grid: *grid, Caption: *caption})
+	case "parchment":
+		img, err = image.CreateParchmentImageFromWorld(w, image.ParchmentOptions{Scale: *scale, Palette: pal})
+	case "heightmap":
+		img, err = image.CreateHeightImage(w, image.HeightmapOptions{})
+	}
+	if err != nil {
+		return invalidInput(err)
+	}
+
+	if err := image.SaveImage(img, *out); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Saved %s\n", *out)
+	return nil
+}
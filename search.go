@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"tilemap-generator/mapgen/batch"
+	"tilemap-generator/mapgen/biome"
+)
+
+func (c *cli) search(args []string) error {
+	fs := c.flags("search")
+	var rf recipeFlags
+	rf.register(fs)
+	seedSpec := fs.String("seeds", "1-10000", "seeds to try, as a list and ranges")
+	constraintsPath := fs.String("constraints", "", "JSON file with constraints; flags below add to it")
+	limit := fs.Int("limit", 10, "number of matching seeds to find")
+	preview := fs.Int64("preview", 1, "check every n-th cell first and skip seeds that fail; faster, but may drop seeds near the limits (1 disables)")
+	workers := fs.Int("workers", 0, "seeds checked at once (default: number of CPUs)")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+
+	var cons batch.Constraints
+	fs.Func("land", "land share range, e.g. 0.35-0.45 or 0.3-", func(s string) error {
+		r, err := parseRange(s)
+		cons.Land = &r
+		return err
+	})
+	fs.Func("group", "share range of a biome group, e.g. Mounts=0.05-0.2 (repeatable)", func(s string) error {
+		name, spec, ok := strings.Cut(s, "=")
+		if !ok || name == "" {
+			return errors.New("want GROUP=MIN-MAX")
+		}
+		r, err := parseRange(spec)
+		if cons.Groups == nil {
+			cons.Groups = make(map[string]batch.Range)
+		}
+		cons.Groups[name] = r
+		return err
+	})
+	fs.Func("region", "connected region of a group with at least N cells, e.g. Mounts:500 or Mounts:500x2 (repeatable)", func(s string) error {
+		name, spec, ok := strings.Cut(s, ":")
+		if !ok || name == "" {
+			return errors.New("want GROUP:CELLS or GROUP:CELLSxCOUNT")
+		}
+		cells, count, _ := strings.Cut(spec, "x")
+		rc := batch.RegionConstraint{Group: name}
+		var err error
+		if rc.MinCells, err = strconv.ParseInt(cells, 10, 64); err != nil {
+			return err
+		}
+		if count != "" {
+			if rc.Count, err = strconv.Atoi(count); err != nil {
+				return err
+			}
+		}
+		cons.Regions = append(cons.Regions, rc)
+		return nil
+	})
+	fs.Func("clear", "no cells of a group within a radius of a cell, e.g. 500,500,20 or 500,500,20,Liquid (repeatable; group defaults to water)", func(s string) error {
+		parts := strings.Split(s, ",")
+		if len(parts) != 3 && len(parts) != 4 {
+			return errors.New("want X,Y,RADIUS[,GROUP]")
+		}
+		var nums [3]int64
+		for i := range nums {
+			n, err := strconv.ParseInt(strings.TrimSpace(parts[i]), 10, 64)
+			if err != nil {
+				return err
+			}
+			nums[i] = n
+		}
+		a := batch.AreaConstraint{X: nums[0], Y: nums[1], Radius: nums[2], Group: biome.WaterGroup}
+		if len(parts) == 4 {
+			a.Group = strings.TrimSpace(parts[3])
+		}
+		cons.Clear = append(cons.Clear, a)
+		return nil
+	})
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	seeds, err := batch.ParseSeeds(*seedSpec)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	if *constraintsPath != "" {
+		file, err := batch.LoadConstraints(*constraintsPath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			return invalidInput(err)
+		}
+		cons = mergeConstraints(file, cons)
+	}
+	if err := cons.Validate(); err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	r, err := rf.load()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	matches, err := batch.Search(ctx, r, seeds, cons, batch.SearchOptions{
+		Workers: *workers,
+		Limit:   *limit,
+		Preview: *preview,
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return invalidInput(err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		if matches == nil {
+			matches = []batch.Match{}
+		}
+		return enc.Encode(matches)
+	}
+
+	if len(matches) == 0 {
+		fmt.Fprintln(c.stdout, "No matching seeds")
+		return nil
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Seed\tScore\tLand\tBiomes\t")
+	for _, m := range matches {
+		fmt.Fprintf(tw, "%d\t%.3f\t%.1f%%\t%d\t\n", m.Seed, m.Score, m.LandRatio*100, len(m.Biomes))
+	}
+	return tw.Flush()
+}
+
+// parseRange разбирает "0.35-0.45"; без верхней границы — "0.3-" или "0.3".
+func parseRange(s string) (batch.Range, error) {
+	lo, hi, _ := strings.Cut(s, "-")
+	var r batch.Range
+	var err error
+	if r.Min, err = strconv.ParseFloat(strings.TrimSpace(lo), 64); err != nil {
+		return r, err
+	}
+	if hi = strings.TrimSpace(hi); hi != "" {
+		v, err := strconv.ParseFloat(hi, 64)
+		r.Max = &v
+		return r, err
+	}
+	return r, nil
+}
+
+// mergeConstraints дополняет условия из файла условиями из флагов; доли из флагов
+// заменяют одноимённые из файла.
+func mergeConstraints(file, flags batch.Constraints) batch.Constraints {
+	if flags.Land != nil {
+		file.Land = flags.Land
+	}
+	for name, r := range flags.Groups {
+		if file.Groups == nil {
+			file.Groups = make(map[string]batch.Range)
+		}
+		file.Groups[name] = r
+	}
+	file.Regions = append(file.Regions, flags.Regions...)
+	file.Clear = append(file.Clear, flags.Clear...)
+	return file
+}
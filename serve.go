@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"tilemap-generator/mapgen/recipe"
+	"tilemap-generator/server"
+)
+
+func (c *cli) serve(args []string) error {
+	fs := c.flags("serve")
+	addr := fs.String("addr", ":8080", "address to listen on")
+	recipePath := fs.String("recipe", "", "recipe to serve (default settings of generate)")
+	tileSize := fs.Int("tile-size", 256, "tile size in pixels")
+	cacheSize := fs.Int("cache", 2048, "number of tiles kept in memory")
+	previewSize := fs.Int("preview-size", 512, "largest side of the live preview in pixels")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	var r *recipe.Recipe
+	var err error
+	if *recipePath != "" {
+		if r, err = recipe.Load(*recipePath); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			return invalidInput(err)
+		}
+	} else if r, err = defaultRecipe(""); err != nil {
+		return err
+	}
+
+	s, err := server.New(r, server.Options{
+		Addr:        *addr,
+		TileSize:    *tileSize,
+		CacheSize:   *cacheSize,
+		PreviewSize: *previewSize,
+	})
+	if err != nil {
+		return invalidInput(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return s.ListenAndServe(ctx)
+}
@@ -0,0 +1,25 @@
+package server
+
+import "testing"
+
+func TestLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newLRUCache(2)
+	c.Put("a", []byte("1"))
+	c.Put("b", []byte("2"))
+
+	// Обращение к a делает самым старым b
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("a is missing")
+	}
+	c.Put("c", []byte("3"))
+
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("b should have been evicted")
+	}
+	if got, ok := c.Get("a"); !ok || string(got) != "1" {
+		t.Errorf("a = %q, %v", got, ok)
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len = %d, want 2", c.Len())
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"strconv"
+	mapimage "tilemap-generator/image"
+	"tilemap-generator/mapgen/recipe"
+	"tilemap-generator/mapgen/world"
+)
+
+// Веб-интерфейс для подбора параметров: статика встроена в бинарь, картинка
+// перестраивается запросом POST /preview с рецептом в теле.
+
+//go:embed ui
+var uiFiles embed.FS
+
+// Ограничения запроса превью: размер тела и объём работы шума на клетку.
+// Частота выше 1 уже даёт независимый шум в каждой клетке.
+const (
+	maxRecipeSize     = 1 << 20
+	maxPreviewOctaves = 10
+	maxPreviewFreq    = 1.0
+)
+
+func checkPreview(rec *recipe.Recipe) error {
+	p := rec.Params
+	switch {
+	case p.Sketch != nil:
+		return errors.New("preview: sketches are not supported")
+	case rec.Heightmap != "":
+		return errors.New("preview: heightmaps are not supported")
+	case p.Noise.Octaves > maxPreviewOctaves:
+		return fmt.Errorf("preview: octaves must not exceed %d", maxPreviewOctaves)
+	case p.Frequency < 0 || p.Frequency > maxPreviewFreq:
+		return fmt.Errorf("preview: frequency must be within 0..%g", maxPreviewFreq)
+	}
+	return nil
+}
+
+func uiHandler() http.Handler {
+	sub, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServerFS(sub)
+}
+
+// handleRecipe отдаёт рецепт сервера — начальное состояние редактора.
+func (s *Server) handleRecipe(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := recipe.Write(&buf, s.recipe); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buf.Bytes())
+}
+
+// handlePreview строит PNG по присланному рецепту. Миры больше PreviewSize
+// строятся через GenerateChunk с шагом, поэтому превью быстрое при любом размере.
+// Эскизы не принимаются: recipe читает их с диска сервера по пути из запроса.
+// Использованный сид возвращается в X-Seed.
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	rec, err := recipe.Read(io.LimitReader(r.Body, maxRecipeSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := checkPreview(rec); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var wld *world.World
+	size := int64(s.opts.PreviewSize)
+	if rec.Config.Width <= size && rec.Config.Height <= size {
+		if wld, err = rec.Generate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		g, err := rec.Generator()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		step := (max(rec.Config.Width, rec.Config.Height) + size - 1) / size
+		width := (rec.Config.Width + step - 1) / step
+		height := (rec.Config.Height + step - 1) / step
+		wld = g.GenerateChunk(rec.Params, world.Point{}, width, height, step)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, mapimage.CreateImageFromWorld(wld)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("X-Seed", strconv.Itoa(wld.Seed))
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("Error writing preview: %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	mapimage "tilemap-generator/image"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/generator"
+	"tilemap-generator/mapgen/recipe"
+	"tilemap-generator/mapgen/world"
+	"time"
+)
+
+// HTTP-сервер, отдающий бесконечный мир по рецепту:
+//
+//	GET /tiles/{z}/{x}/{y}.png — тайл, генерируется по запросу через GenerateChunk
+//	GET /world.json            — рецепт и параметры сетки тайлов
+//	GET /cell?x=&y=            — высота и биом клетки
+//	GET /                      — редактор параметров с превью (см. preview.go)
+//
+// На уровне NativeZoom клетка равна пикселю, ниже — берётся каждая 2^n-я клетка,
+// выше — клетки растягиваются. Координаты тайлов могут быть отрицательными.
+
+type Options struct {
+	// Default: ":8080".
+	Addr string
+	// Default: 256.
+	TileSize int
+	// Уровень, на котором клетка равна пикселю. nil — уровень, на котором
+	// мир из Config рецепта помещается в один тайл на нулевом уровне.
+	NativeZoom *int
+	// Сколько тайлов держать в памяти. Default: 2048.
+	CacheSize int
+	// Сколько ждать завершения запросов при остановке. Default: 5s.
+	ShutdownTimeout time.Duration
+	// Наибольшая сторона превью в пикселях. Default: 512.
+	PreviewSize int
+}
+
+type Server struct {
+	recipe    *recipe.Recipe
+	generator *generator.WorldGenerator
+	biomes    []biome.WorldBiome
+	opts      Options
+	cache     *lruCache
+	// Уровень из Options.NativeZoom или вычисленный по Config.
+	nativeZoom int
+}
+
+func New(r *recipe.Recipe, opts Options) (*Server, error) {
+	// Тайлы строятся через GenerateChunk по шуму, без эскиза и карты высот:
+	// карта в браузере разошлась бы с generate по тому же рецепту
+	if r.Params.Sketch != nil {
+		return nil, errors.New("server: recipes with a sketch are not supported")
+	}
+	if r.Heightmap != "" {
+		return nil, errors.New("server: recipes with a heightmap are not supported")
+	}
+	g, err := r.Generator()
+	if err != nil {
+		return nil, err
+	}
+	biomes, err := r.Biomes.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Addr == "" {
+		opts.Addr = ":8080"
+	}
+	if opts.TileSize <= 0 {
+		opts.TileSize = 256
+	}
+	var nativeZoom int
+	if opts.NativeZoom != nil {
+		if nativeZoom = *opts.NativeZoom; nativeZoom < 0 {
+			return nil, fmt.Errorf("server: native zoom must not be negative, got %d", nativeZoom)
+		}
+	} else {
+		tiles := float64(max(r.Config.Width, r.Config.Height)) / float64(opts.TileSize)
+		nativeZoom = max(0, int(math.Ceil(math.Log2(tiles))))
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = 2048
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = 5 * time.Second
+	}
+	if opts.PreviewSize <= 0 {
+		opts.PreviewSize = 512
+	}
+
+	// Все тайлы должны быть из одного мира, поэтому сид фиксируется при старте —
+	// в копии, рецепт вызывающего не меняется
+	if r.Params.Seed == 0 {
+		fixed := *r
+		fixed.Params.Seed = int(time.Now().Unix())
+		r = &fixed
+	}
+
+	return &Server{
+		recipe:     r,
+		generator:  g,
+		biomes:     biomes,
+		opts:       opts,
+		cache:      newLRUCache(opts.CacheSize),
+		nativeZoom: nativeZoom,
+	}, nil
+}
+
+// MaxZoom — самый крупный уровень: клетка занимает весь тайл.
+func (s *Server) MaxZoom() int {
+	return s.nativeZoom + int(math.Log2(float64(s.opts.TileSize)))
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tiles/{z}/{x}/{y}", s.handleTile)
+	mux.HandleFunc("GET /world.json", s.handleWorld)
+	mux.HandleFunc("GET /cell", s.handleCell)
+	mux.HandleFunc("GET /recipe.json", s.handleRecipe)
+	mux.HandleFunc("POST /preview", s.handlePreview)
+	mux.Handle("GET /", uiHandler())
+	return mux
+}
+
+// ListenAndServe работает до отмены ctx, затем дожидается текущих запросов
+// не дольше ShutdownTimeout.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.opts.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	log.Printf("Serving seed %d on %s", s.recipe.Params.Seed, s.opts.Addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.opts.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleTile(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutSuffix(r.PathValue("y"), ".png")
+	z, errZ := strconv.Atoi(r.PathValue("z"))
+	x, errX := strconv.ParseInt(r.PathValue("x"), 10, 64)
+	y, errY := strconv.ParseInt(name, 10, 64)
+	if !ok || errZ != nil || errX != nil || errY != nil {
+		http.Error(w, "tile path must be /tiles/{z}/{x}/{y}.png", http.StatusBadRequest)
+		return
+	}
+	if z < 0 || z > s.MaxZoom() {
+		http.Error(w, fmt.Sprintf("zoom must be within 0..%d", s.MaxZoom()), http.StatusNotFound)
+		return
+	}
+
+	key := fmt.Sprintf("%d/%d/%d", z, x, y)
+	data, ok := s.cache.Get(key)
+	if !ok {
+		var err error
+		data, err = s.renderTile(z, x, y)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.cache.Put(key, data)
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Write(data)
+}
+
+// renderTile генерирует ровно те клетки, что видны в тайле: на мелких уровнях
+// чанк строится с шагом, а не генерируется целиком и уменьшается.
+func (s *Server) renderTile(z int, x, y int64) ([]byte, error) {
+	size := int64(s.opts.TileSize)
+	step, pixels := int64(1), int64(1)
+	if z <= s.nativeZoom {
+		step = 1 << (s.nativeZoom - z)
+	} else {
+		pixels = 1 << (z - s.nativeZoom)
+	}
+
+	samples := (size + pixels - 1) / pixels
+	origin := world.Point{X: x * samples * step, Y: y * samples * step}
+	chunk := s.generator.GenerateChunk(s.recipe.Params, origin, samples, samples, step)
+
+	img := mapimage.CreateImageFromWorld(chunk)
+	if pixels > 1 {
+		img = upscale(img, int(pixels), int(size))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func upscale(src image.Image, factor, size int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dst.Set(x, y, src.At(x/factor, y/factor))
+		}
+	}
+	return dst
+}
+
+type worldInfo struct {
+	Seed       int                            `json:"seed"`
+	Tiles      string                         `json:"tiles"`
+	TileSize   int                            `json:"tile_size"`
+	MinZoom    int                            `json:"min_zoom"`
+	MaxZoom    int                            `json:"max_zoom"`
+	NativeZoom int                            `json:"native_zoom"`
+	Config     world.Config                   `json:"config"`
+	Params     generator.WorldGeneratorParams `json:"params"`
+	Biomes     []biomeInfo                    `json:"biomes"`
+}
+
+type biomeInfo struct {
+	LowerBound float64 `json:"lower"`
+	UpperBound float64 `json:"upper"`
+	biome.Data
+}
+
+func (s *Server) handleWorld(w http.ResponseWriter, r *http.Request) {
+	info := worldInfo{
+		Seed:       s.recipe.Params.Seed,
+		Tiles:      "/tiles/{z}/{x}/{y}.png",
+		TileSize:   s.opts.TileSize,
+		MaxZoom:    s.MaxZoom(),
+		NativeZoom: s.nativeZoom,
+		Config:     s.recipe.Config,
+		Params:     s.recipe.Params,
+	}
+	for _, b := range s.biomes {
+		info.Biomes = append(info.Biomes, biomeInfo{b.LowerBound, b.UpperBound, b.Data})
+	}
+
+	writeJSON(w, info)
+}
+
+type cellInfo struct {
+	X      int64       `json:"x"`
+	Y      int64       `json:"y"`
+	Height float64     `json:"height"`
+	Biome  *biome.Data `json:"biome"`
+}
+
+func (s *Server) handleCell(w http.ResponseWriter, r *http.Request) {
+	x, errX := strconv.ParseInt(r.URL.Query().Get("x"), 10, 64)
+	y, errY := strconv.ParseInt(r.URL.Query().Get("y"), 10, 64)
+	if errX != nil || errY != nil {
+		http.Error(w, "x and y must be integers", http.StatusBadRequest)
+		return
+	}
+
+	chunk := s.generator.GenerateChunk(s.recipe.Params, world.Point{X: x, Y: y}, 1, 1, 1)
+	info := cellInfo{X: x, Y: y, Height: chunk.Heights[0][0]}
+	// Клетка вне всех диапазонов без запасного биома остаётся нулевой
+	if b := chunk.Matrix[0][0]; b != (biome.Data{}) {
+		info.Biome = &b
+	}
+
+	writeJSON(w, info)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"tilemap-generator/mapgen/biome"
+	"tilemap-generator/mapgen/generator"
+	"tilemap-generator/mapgen/recipe"
+	"tilemap-generator/mapgen/world"
+	"time"
+)
+
+func testServer(t *testing.T) *Server {
+	t.Helper()
+	set := biome.NewSet()
+	set.Add(0, 0.5, biome.Data{Name: "Liquid", NameRU: "Океан", Color: "#4292c4"})
+	set.Add(0.5, 1, biome.Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"})
+	r := recipe.New(world.Config{Width: 64, Height: 64}, generator.WorldGeneratorParams{Seed: 11, Frequency: 0.05}, set)
+
+	s, err := New(r, Options{TileSize: 16, CacheSize: 4})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s
+}
+
+func TestTileMatchesChunk(t *testing.T) {
+	s := testServer(t)
+	if s.nativeZoom != 2 {
+		t.Fatalf("NativeZoom = %d, want 2", s.nativeZoom)
+	}
+
+	// Отрицательные координаты — обычная часть бесконечного мира
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/tiles/2/-1/3.png", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("status %d, %s", rec.Code, rec.Body)
+	}
+	img, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if img.Bounds().Dx() != 16 {
+		t.Fatalf("tile size = %d, want 16", img.Bounds().Dx())
+	}
+
+	chunk := s.generator.GenerateChunk(s.recipe.Params, world.Point{X: -16 + 5, Y: 48 + 7}, 1, 1, 1)
+	want := map[string]color.RGBA{"Океан": {0x42, 0x92, 0xc4, 255}, "Луга": {0x5d, 0xbc, 0x21, 255}}[chunk.Matrix[0][0].NameRU]
+	if got := color.RGBAModel.Convert(img.At(5, 7)); got != want {
+		t.Errorf("pixel (5, 7) = %v, want %v", got, want)
+	}
+
+	if s.cache.Len() != 1 {
+		t.Errorf("cache has %d tiles, want 1", s.cache.Len())
+	}
+}
+
+func TestTileErrors(t *testing.T) {
+	s := testServer(t)
+	for path, code := range map[string]int{
+		"/tiles/2/0/0.jpg":  http.StatusBadRequest,
+		"/tiles/a/0/0.png":  http.StatusBadRequest,
+		"/tiles/99/0/0.png": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != code {
+			t.Errorf("%s: status %d, want %d", path, rec.Code, code)
+		}
+	}
+}
+
+func TestCellAndWorld(t *testing.T) {
+	s := testServer(t)
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/cell?x=10&y=-4", nil))
+	var cell cellInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &cell); err != nil {
+		t.Fatalf("cell: %v (%s)", err, rec.Body)
+	}
+	if cell.X != 10 || cell.Y != -4 || cell.Biome == nil {
+		t.Errorf("unexpected cell %+v", cell)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/cell?x=abc&y=1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("bad cell query: status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/world.json", nil))
+	var info worldInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("world.json: %v", err)
+	}
+	if info.Seed != 11 || info.MaxZoom != 6 || len(info.Biomes) != 2 || info.Biomes[1].NameRU != "Луга" {
+		t.Errorf("unexpected world info %+v", info)
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	s := testServer(t)
+	s.opts.Addr = "127.0.0.1:0"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.ListenAndServe(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("ListenAndServe: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop")
+	}
+}
+
+func TestPreview(t *testing.T) {
+	s := testServer(t)
+	s.opts.PreviewSize = 32
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "app.js") {
+		t.Fatalf("UI: status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/recipe.json", nil))
+	r, err := recipe.Read(rec.Body)
+	if err != nil {
+		t.Fatalf("recipe.json: %v", err)
+	}
+
+	// Мир 64×64 больше превью, поэтому строится с шагом 2
+	var body bytes.Buffer
+	recipe.Write(&body, r)
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/preview", &body))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("preview: status %d: %s", rec.Code, rec.Body)
+	}
+	img, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 32 || b.Dy() != 32 || rec.Header().Get("X-Seed") != "11" {
+		t.Errorf("preview %v, seed %q", b, rec.Header().Get("X-Seed"))
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/preview", strings.NewReader(`{"version": 1}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid recipe: status %d", rec.Code)
+	}
+}
+
+func TestPreviewRejectsUntrustedRecipes(t *testing.T) {
+	s := testServer(t)
+
+	for name, edit := range map[string]func(r *recipe.Recipe){
+		// Путь эскиза открывался бы на сервере, а ошибка уходила бы клиенту
+		"sketch": func(r *recipe.Recipe) {
+			r.Params.Sketch = &generator.Sketch{
+				Path:     "/etc/passwd",
+				Colors:   []generator.SketchColor{{Color: "#000000", Biome: "Океан"}},
+				Strength: 1,
+			}
+		},
+		"octaves":   func(r *recipe.Recipe) { r.Params.Noise.Octaves = 1000 },
+		"frequency": func(r *recipe.Recipe) { r.Params.Frequency = 1e6 },
+	} {
+		r := *s.recipe
+		edit(&r)
+		var body bytes.Buffer
+		if err := recipe.Write(&body, &r); err != nil {
+			t.Fatalf("%s: Write: %v", name, err)
+		}
+
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/preview", &body))
+		if rec.Code != http.StatusBadRequest || strings.Contains(rec.Body.String(), "passwd") {
+			t.Errorf("%s: status %d: %s", name, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	set := biome.NewSet()
+	set.Add(0, 1, biome.Data{Name: "Fields", NameRU: "Луга", Color: "#5dbc21"})
+	config := world.Config{Width: 64, Height: 64}
+
+	zero := 0
+	s, err := New(recipe.New(config, generator.WorldGeneratorParams{Seed: 1}, set), Options{TileSize: 16, NativeZoom: &zero})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if s.nativeZoom != 0 || s.MaxZoom() != 4 {
+		t.Errorf("native zoom %d, max zoom %d, want 0 and 4", s.nativeZoom, s.MaxZoom())
+	}
+
+	sketch := &generator.Sketch{Path: "mask.png", Colors: []generator.SketchColor{{Color: "#00ff00", Biome: "Fields"}}, Strength: 1}
+	if _, err := New(recipe.New(config, generator.WorldGeneratorParams{Seed: 1, Sketch: sketch}, set), Options{}); err == nil {
+		t.Error("recipe with a sketch accepted")
+	}
+	// Сид по времени фиксируется в копии, а не в рецепте вызывающего
+	unseeded := recipe.New(config, generator.WorldGeneratorParams{}, set)
+	if s, err = New(unseeded, Options{}); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if unseeded.Params.Seed != 0 || s.recipe.Params.Seed == 0 {
+		t.Errorf("caller seed %d, server seed %d", unseeded.Params.Seed, s.recipe.Params.Seed)
+	}
+
+	heightmap := recipe.New(config, generator.WorldGeneratorParams{Seed: 1}, set)
+	heightmap.Heightmap = "terrain.png"
+	if _, err := New(heightmap, Options{}); err == nil {
+		t.Error("recipe with a heightmap accepted")
+	}
+}
@@ -0,0 +1,204 @@
+"use strict";
+
+// Состояние редактора — рецепт в том же формате, что и файлы *.recipe.json.
+let recipe = null;
+
+const NOISE_TYPES = ["OpenSimplex2S", "OpenSimplex2", "Perlin", "Cellular", "ValueCubic", "Value"];
+const FRACTAL_TYPES = ["None", "FBm", "Ridged", "PingPong"];
+
+// Ползунки: путь к полю рецепта, диапазон и значение по умолчанию, если поле не задано.
+const SLIDERS = {
+  "sliders": [
+    { path: ["params", "frequency"], label: "Frequency", min: 0.0005, max: 0.05, step: 0.0005, def: 0.004 },
+    { path: ["params", "offset_x"], label: "Offset X", min: -5000, max: 5000, step: 1, def: 0 },
+    { path: ["params", "offset_y"], label: "Offset Y", min: -5000, max: 5000, step: 1, def: 0 },
+  ],
+  "noise-sliders": [
+    { path: ["params", "noise", "octaves"], label: "Octaves", min: 1, max: 8, step: 1, def: 3 },
+    { path: ["params", "noise", "lacunarity"], label: "Lacunarity", min: 1, max: 4, step: 0.05, def: 2 },
+    { path: ["params", "noise", "gain"], label: "Gain", min: 0, max: 1, step: 0.01, def: 0.5 },
+    { path: ["params", "noise", "weighted_strength"], label: "Weighted strength", min: 0, max: 1, step: 0.01, def: 0 },
+  ],
+  "config-sliders": [
+    { path: ["config", "width"], label: "Width", min: 16, max: 4096, step: 16, def: 1000 },
+    { path: ["config", "height"], label: "Height", min: 16, max: 4096, step: 16, def: 1000 },
+  ],
+};
+
+function get(obj, path) {
+  return path.reduce((o, key) => (o == null ? undefined : o[key]), obj);
+}
+
+function set(obj, path, value) {
+  const last = path[path.length - 1];
+  const parent = path.slice(0, -1).reduce((o, key) => (o[key] ??= {}), obj);
+  parent[last] = value;
+}
+
+function buildSliders() {
+  for (const [container, sliders] of Object.entries(SLIDERS)) {
+    const root = document.getElementById(container);
+    root.textContent = "";
+    for (const s of sliders) {
+      const field = document.createElement("div");
+      field.className = "field";
+      const label = document.createElement("label");
+      const output = document.createElement("output");
+      const input = document.createElement("input");
+      Object.assign(input, { type: "range", min: s.min, max: s.max, step: s.step });
+      input.value = get(recipe, s.path) ?? s.def;
+      output.value = input.value;
+      label.append(s.label, output);
+      input.addEventListener("input", () => {
+        output.value = input.value;
+        set(recipe, s.path, Number(input.value));
+        schedulePreview();
+      });
+      field.append(label, input);
+      root.append(field);
+    }
+  }
+}
+
+function fillSelect(id, values, path) {
+  const select = document.getElementById(id);
+  select.textContent = "";
+  for (const v of values) {
+    select.append(new Option(v, v));
+  }
+  select.value = get(recipe, path) || values[0];
+  select.onchange = () => {
+    set(recipe, path, select.value);
+    schedulePreview();
+  };
+}
+
+function buildBiomeTable() {
+  const tbody = document.getElementById("biome-rows");
+  tbody.textContent = "";
+  recipe.biomes.biomes.forEach((b, i) => {
+    const row = document.createElement("tr");
+    const cell = (type, key, parse = (v) => v) => {
+      const input = document.createElement("input");
+      input.type = type;
+      if (type === "number") input.step = key === "priority" ? 1 : 0.01;
+      input.value = b[key] ?? (type === "number" ? 0 : "");
+      input.addEventListener("change", () => {
+        b[key] = parse(input.value);
+        schedulePreview();
+      });
+      const td = document.createElement("td");
+      td.append(input);
+      row.append(td);
+    };
+    cell("number", "lower", Number);
+    cell("number", "upper", Number);
+    cell("number", "priority", Number);
+    cell("text", "name");
+    cell("text", "name_ru");
+    cell("color", "color");
+
+    const remove = document.createElement("button");
+    remove.type = "button";
+    remove.textContent = "✕";
+    remove.onclick = () => {
+      recipe.biomes.biomes.splice(i, 1);
+      buildBiomeTable();
+      schedulePreview();
+    };
+    const td = document.createElement("td");
+    td.append(remove);
+    row.append(td);
+    tbody.append(row);
+  });
+}
+
+function buildForm() {
+  document.getElementById("seed").value = recipe.params.seed ?? 0;
+  fillSelect("noise-type", NOISE_TYPES, ["params", "noise", "type"]);
+  fillSelect("noise-fractal", FRACTAL_TYPES, ["params", "noise", "fractal"]);
+  buildSliders();
+  buildBiomeTable();
+}
+
+let timer = null;
+let inflight = null;
+// Сид показанной карты: при seed 0 сервер выбирает его сам и возвращает в X-Seed
+let shownSeed = null;
+
+function schedulePreview() {
+  clearTimeout(timer);
+  timer = setTimeout(preview, 200);
+}
+
+async function preview() {
+  // Устаревший запрос отменяется, чтобы картинка соответствовала последним настройкам
+  inflight?.abort();
+  inflight = new AbortController();
+  shownSeed = null;
+  const status = document.getElementById("status");
+  status.className = "";
+  status.textContent = "Generating…";
+
+  try {
+    const started = performance.now();
+    const res = await fetch("preview", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(recipe),
+      signal: inflight.signal,
+    });
+    if (!res.ok) {
+      throw new Error(await res.text());
+    }
+    const blob = await res.blob();
+    const img = document.getElementById("map");
+    URL.revokeObjectURL(img.src);
+    img.src = URL.createObjectURL(blob);
+    shownSeed = Number(res.headers.get("X-Seed"));
+    status.textContent = `Seed ${shownSeed} · ${Math.round(performance.now() - started)} ms`;
+  } catch (err) {
+    if (err.name === "AbortError") return;
+    status.className = "error";
+    status.textContent = err.message;
+  }
+}
+
+function download() {
+  // Скачанный рецепт должен воспроизводить карту на экране, а не новый случайный сид
+  if (shownSeed !== null) {
+    recipe.params.seed = shownSeed;
+    document.getElementById("seed").value = shownSeed;
+  }
+  const blob = new Blob([JSON.stringify(recipe, null, 2) + "\n"], { type: "application/json" });
+  const link = document.createElement("a");
+  link.href = URL.createObjectURL(blob);
+  link.download = "world.recipe.json";
+  link.click();
+  URL.revokeObjectURL(link.href);
+}
+
+document.getElementById("seed").addEventListener("change", (e) => {
+  recipe.params.seed = Math.trunc(Number(e.target.value));
+  schedulePreview();
+});
+document.getElementById("random-seed").addEventListener("click", () => {
+  recipe.params.seed = Math.floor(Math.random() * 2 ** 31);
+  document.getElementById("seed").value = recipe.params.seed;
+  schedulePreview();
+});
+document.getElementById("add-biome").addEventListener("click", () => {
+  const last = recipe.biomes.biomes[recipe.biomes.biomes.length - 1];
+  recipe.biomes.biomes.push({ lower: last?.upper ?? 0, upper: 1, name: "New", name_ru: "Новый", color: "#888888" });
+  buildBiomeTable();
+  schedulePreview();
+});
+document.getElementById("download").addEventListener("click", download);
+
+fetch("recipe.json")
+  .then((res) => res.json())
+  .then((data) => {
+    recipe = data;
+    buildForm();
+    preview();
+  });
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"encoding/json"
+	"tilemap-generator/mapgen/world"
+)
+
+func (c *cli) stats(args []string) error {
+	fs := c.flags("stats")
+	var src source
+	src.register(fs)
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	bins := fs.Int("bins", 10, "number of height histogram bins")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	w, err := src.load()
+	if err != nil {
+		return err
+	}
+	stats := w.Stats(world.StatsOptions{HistogramBins: *bins})
+
+	if *asJSON {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	return stats.WriteTable(c.stdout)
+}